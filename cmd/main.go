@@ -1,12 +1,16 @@
 package main
 
 import (
-	"fileutil"
+	"context"
 	"fmt"
+	"log"
 	"os"
-	"parser"
 	"path/filepath"
+	"runtime"
 
+	"github.com/fdbiondi/vuex-pinia-migration-tool/pkg/fileutil"
+	"github.com/fdbiondi/vuex-pinia-migration-tool/pkg/migrate"
+	"github.com/fdbiondi/vuex-pinia-migration-tool/pkg/parser"
 	"github.com/spf13/cobra"
 )
 
@@ -14,6 +18,18 @@ var (
 	verbose    bool
 	debug      bool
 	removeDest bool
+	components string
+	format     string
+	framework  string
+	hmr        bool
+	typedState bool
+	lang       string
+	modules    []string
+	force      bool
+	inPlace    bool
+	jobs       int
+	noCache    bool
+	watch      bool
 )
 
 var rootCmd = &cobra.Command{
@@ -26,86 +42,170 @@ func main() {
 	migrateCmd := &cobra.Command{
 		Use:   "migrate [source_path] [destination_path]",
 		Short: "Translates code from a source directory written in vuex to an output directory",
-		Args:  cobra.MinimumNArgs(2),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if inPlace {
+				return cobra.ExactArgs(1)(cmd, args)
+			}
+
+			return cobra.MinimumNArgs(2)(cmd, args)
+		},
 		RunE: func(_ *cobra.Command, args []string) error {
-			// set flags
-			parser.Verbose = verbose
-			parser.Debug = debug
+			if watch && inPlace {
+				return fmt.Errorf("--watch migrates the source directory into a destination, it can not be used with --in-place")
+			}
 
-			// grab directories
-			sourceDir, err := filepath.Abs(args[0])
+			if jobs < 1 {
+				return fmt.Errorf("jobs must be at least 1")
+			}
+
+			var options = migrate.Options{
+				Source:            args[0],
+				RemoveDestination: removeDest,
+				Config: migrate.Config{
+					Framework:  framework,
+					Lang:       lang,
+					HMR:        hmr,
+					TypedState: typedState,
+					Force:      force,
+					NoCache:    noCache,
+					Modules:    modules,
+					Jobs:       jobs,
+					Verbose:    verbose,
+					Debug:      debug,
+					Logger:     log.New(os.Stdout, "", 0),
+				},
+			}
+
+			// the modules migrated by a previous run are skipped
+			if !inPlace {
+				options.Destination = args[1]
+			}
+
+			_, err := migrate.Migrate(context.Background(), options)
 			if err != nil {
 				return err
 			}
-			destDir, err := filepath.Abs(args[1])
+
+			fmt.Println("\nmigration complete!")
+
+			if !watch {
+				return nil
+			}
+
+			sourceDir, err := filepath.Abs(options.Source)
 			if err != nil {
 				return err
 			}
 
-			if !fileutil.Exists(sourceDir) {
-				return fmt.Errorf("source directory '%s' does not exist", sourceDir)
+			destDir, err := filepath.Abs(options.Destination)
+			if err != nil {
+				return err
 			}
 
-			if removeDest {
-				err := os.RemoveAll(destDir)
+			return watchSource(sourceDir, func(paths []string) error {
+				before, err := fileutil.ReadTree(destDir)
+				if err != nil {
+					return err
+				}
+
+				fmt.Printf("\n%s changed, migrating again\n", sourceDir)
+
+				// only the modules affected by the changed files are migrated
+				_, err = migrate.Update(context.Background(), options, paths)
 				if err != nil {
 					return err
 				}
-			} else {
-				if fileutil.Exists(destDir) {
-					// move old dir
-					err = fileutil.VersionDir(destDir)
-					if err != nil {
-						return err
-					}
-
-					// create new dir
-					err = fileutil.CreateIfNotExists(destDir, 0755)
-					if err != nil {
-						return err
-					}
+
+				after, err := fileutil.ReadTree(destDir)
+				if err != nil {
+					return err
 				}
-			}
 
-			err = fileutil.CopyDirectory(sourceDir, destDir)
+				printTreeDiff(before, after)
+
+				return nil
+			})
+		},
+	}
+
+	migrateCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	migrateCmd.PersistentFlags().BoolVarP(&removeDest, "remove-destination", "r", false, "remove destination directory")
+	migrateCmd.PersistentFlags().BoolVarP(&debug, "debug-mode", "d", false, "enable debug mode")
+	migrateCmd.PersistentFlags().BoolVar(&hmr, "hmr", false, "accept the hot updates of the stores with vite")
+	migrateCmd.PersistentFlags().BoolVar(&typedState, "typed-state", false, "infer the state types of javascript modules")
+	migrateCmd.PersistentFlags().StringVar(&lang, "lang", parser.LANG_AUTO, "language of the created files: js, ts or auto")
+	migrateCmd.PersistentFlags().StringSliceVar(&modules, "modules", []string{}, "paths of the modules to migrate, e.g. cart,cart/items")
+	migrateCmd.PersistentFlags().BoolVar(&inPlace, "in-place", false, "migrate the source directory instead of a copy")
+	migrateCmd.PersistentFlags().BoolVar(&force, "force", false, "migrate again the modules migrated by a previous run")
+	migrateCmd.PersistentFlags().BoolVarP(&watch, "watch", "w", false, "migrate the changed modules again when the source directory changes")
+	migrateCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "migrate every module again instead of reusing the previous run")
+	migrateCmd.PersistentFlags().IntVarP(&jobs, "jobs", "j", runtime.NumCPU(), "number of modules migrated at the same time")
+	migrateCmd.PersistentFlags().StringVar(&framework, "framework", "", "framework of the store, e.g. nuxt2")
+
+	analyzeCmd := &cobra.Command{
+		Use:   "analyze [source_path]",
+		Short: "Reports the vuex usage of each module of a store directory before migrating it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			sourceDir, err := filepath.Abs(args[0])
 			if err != nil {
 				return err
 			}
 
-			if parser.Verbose {
-				fmt.Printf("source path '%s'\n", sourceDir)
-				fmt.Printf("output path '%s'\n\n", destDir)
+			if !fileutil.Exists(sourceDir) {
+				return fmt.Errorf("source directory '%s' does not exist", sourceDir)
+			}
+
+			if components != "" {
+				components, err = filepath.Abs(components)
+				if err != nil {
+					return err
+				}
 			}
 
-			mod := parser.NewModule(destDir)
-			err = mod.Parse()
+			return parser.Analyze(sourceDir, components)
+		},
+	}
+
+	analyzeCmd.Flags().StringVar(&components, "components", "", "directory of the components using the store")
+
+	graphCmd := &cobra.Command{
+		Use:   "graph [source_path]",
+		Short: "Prints the dependencies between the modules of a store directory",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			sourceDir, err := filepath.Abs(args[0])
 			if err != nil {
 				return err
-			} else {
-				fmt.Println("\nmigration complete!")
 			}
 
-			return nil
+			if !fileutil.Exists(sourceDir) {
+				return fmt.Errorf("source directory '%s' does not exist", sourceDir)
+			}
+
+			return parser.Graph(sourceDir, format)
 		},
 	}
 
-	migrateCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
-	migrateCmd.PersistentFlags().BoolVarP(&removeDest, "remove-destination", "r", false, "remove destination directory")
-	migrateCmd.PersistentFlags().BoolVarP(&debug, "debug-mode", "d", false, "enable debug mode")
+	graphCmd.Flags().StringVar(&format, "format", parser.GRAPH_DOT, "output format: dot, mermaid or json")
 
 	var versionCmd = &cobra.Command{
 		Use:   "version",
 		Short: "Print the version number of Vuex2Pinia",
 		Long:  `All software has versions. This is Vuex2Pinia's`,
 		Run: func(_ *cobra.Command, _ []string) {
-			fmt.Println("Vuex2Pinia migrate tool v0.1")
+			fmt.Printf("Vuex2Pinia migrate tool v%s\n", parser.VERSION)
 		},
 	}
 
-	rootCmd.SetVersionTemplate("Vuex2Pinia migrate tool v0.1")
+	rootCmd.SetVersionTemplate(fmt.Sprint("Vuex2Pinia migrate tool v", parser.VERSION))
 
 	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(analyzeCmd)
+	rootCmd.AddCommand(graphCmd)
 	rootCmd.AddCommand(versionCmd)
 
-	rootCmd.Execute()
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/fdbiondi/vuex-pinia-migration-tool/pkg/fileutil"
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// editors write a file in several steps, the migration waits for the
+	// last change
+	WATCH_DELAY = 300 * time.Millisecond
+	// interval between the checks of the source directory when file
+	// notifications are not available
+	POLL_INTERVAL = time.Second
+)
+
+// watchSource calls migrate with the changed paths each time the files of
+// the source directory change, until the process is interrupted
+func watchSource(sourceDir string, migrate func(paths []string) error) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		err = watchDirs(watcher, sourceDir)
+	}
+
+	if err != nil {
+		fmt.Printf("\nfile notifications not available (%s), polling %s\n", err, sourceDir)
+		return pollSource(sourceDir, migrate)
+	}
+
+	defer watcher.Close()
+
+	fmt.Printf("\nwatching %s\n", sourceDir)
+
+	var timer <-chan time.Time
+	var changed = []string{}
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := watchDirs(watcher, event.Name); err != nil && verbose {
+						fmt.Println("Err: ", err)
+					}
+				}
+			}
+
+			if !slices.Contains(changed, event.Name) {
+				changed = append(changed, event.Name)
+			}
+
+			timer = time.After(WATCH_DELAY)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+
+			fmt.Println("Err: ", err)
+		case <-timer:
+			var paths = changed
+			timer, changed = nil, []string{}
+
+			if err := migrate(paths); err != nil {
+				fmt.Println("Err: ", err)
+			}
+		}
+	}
+}
+
+// watchDirs adds the directory and its sub directories to the watcher
+func watchDirs(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return err
+		}
+
+		return watcher.Add(path)
+	})
+}
+
+// pollSource calls migrate with the changed paths when the modification
+// time or the size of the files of the source directory change
+func pollSource(sourceDir string, migrate func(paths []string) error) error {
+	previous, err := sourceState(sourceDir)
+	if err != nil {
+		return err
+	}
+
+	for {
+		time.Sleep(POLL_INTERVAL)
+
+		current, err := sourceState(sourceDir)
+		if err != nil {
+			return err
+		}
+
+		var paths = changedPaths(previous, current)
+		if len(paths) == 0 {
+			continue
+		}
+
+		previous = current
+
+		if err := migrate(paths); err != nil {
+			fmt.Println("Err: ", err)
+		}
+	}
+}
+
+// changedPaths returns the files added, removed or modified between two
+// states of the source directory
+func changedPaths(previous map[string]string, current map[string]string) []string {
+	var paths = []string{}
+
+	for path, state := range current {
+		if previous[path] != state {
+			paths = append(paths, path)
+		}
+	}
+
+	for path := range previous {
+		if _, ok := current[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+func sourceState(root string) (map[string]string, error) {
+	var state = map[string]string{}
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		state[path] = fmt.Sprint(info.ModTime().UnixNano(), " ", info.Size())
+
+		return nil
+	})
+
+	return state, err
+}
+
+// printTreeDiff prints the diff of the files of the destination changed by
+// the migration
+func printTreeDiff(before map[string]string, after map[string]string) {
+	var paths = []string{}
+
+	for path := range before {
+		paths = append(paths, path)
+	}
+
+	for path := range after {
+		if _, ok := before[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+
+	sort.Strings(paths)
+
+	var changed = 0
+
+	for _, path := range paths {
+		if diff := fileutil.Diff(path, before[path], after[path]); diff != "" {
+			fmt.Print("\n", diff)
+			changed++
+		}
+	}
+
+	if changed == 0 {
+		fmt.Println("\nno changes in the destination")
+	}
+}
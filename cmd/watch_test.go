@@ -0,0 +1,15 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestChangedPaths(t *testing.T) {
+	var previous = map[string]string{"cart/state.js": "1 10", "user/state.js": "1 10", "auth/state.js": "1 10"}
+	var current = map[string]string{"cart/state.js": "2 12", "user/state.js": "1 10", "items/state.js": "1 10"}
+
+	if paths := changedPaths(previous, current); !slices.Equal(paths, []string{"auth/state.js", "cart/state.js", "items/state.js"}) {
+		t.Errorf("changedPaths = %v", paths)
+	}
+}
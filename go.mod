@@ -2,8 +2,13 @@ module github.com/fdbiondi/vuex-pinia-migration-tool
 
 go 1.21.0
 
+require (
+	github.com/fsnotify/fsnotify v1.7.0
+	github.com/spf13/cobra v1.8.0
+)
+
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/cobra v1.8.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
+	golang.org/x/sys v0.13.0 // indirect
 )
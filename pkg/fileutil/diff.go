@@ -0,0 +1,182 @@
+package fileutil
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// lines of context around the changes of a diff
+const DIFF_CONTEXT = 3
+
+// ReadTree returns the content of the files of a directory keyed by their
+// relative path, hidden files and directories are skipped
+func ReadTree(root string) (map[string]string, error) {
+	var tree = map[string]string{}
+
+	if !Exists(root) {
+		return tree, nil
+	}
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path != root && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		tree[filepath.ToSlash(rel)] = string(content)
+
+		return nil
+	})
+
+	return tree, err
+}
+
+// line of a diff, kind is ' ' for unchanged lines, '-' for removed lines and
+// '+' for added lines
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// Diff returns the unified diff of two versions of a file, or an empty
+// string when they are equal
+func Diff(name string, before string, after string) string {
+	if before == after {
+		return ""
+	}
+
+	var lines = diffLines(splitLines(before), splitLines(after))
+	var result = []string{fmt.Sprintf("--- a/%s", name), fmt.Sprintf("+++ b/%s", name)}
+
+	for start := 0; start < len(lines); {
+		// first change not printed yet
+		var first = start
+		for first < len(lines) && lines[first].kind == ' ' {
+			first++
+		}
+
+		if first == len(lines) {
+			break
+		}
+
+		// the hunk ends when the next change is far enough
+		var last = first
+		for index := first; index < len(lines) && index-last <= 2*DIFF_CONTEXT; index++ {
+			if lines[index].kind != ' ' {
+				last = index
+			}
+		}
+
+		var from = max(first-DIFF_CONTEXT, start)
+		var to = min(last+DIFF_CONTEXT+1, len(lines))
+
+		result = append(result, hunkHeader(lines, from, to))
+		for _, line := range lines[from:to] {
+			result = append(result, fmt.Sprint(string(line.kind), line.text))
+		}
+
+		start = to
+	}
+
+	return fmt.Sprintln(strings.Join(result, "\n"))
+}
+
+// diffLines returns the lines of both versions from their longest common
+// subsequence
+func diffLines(before []string, after []string) []diffLine {
+	// common lines of the suffixes of both versions
+	var common = make([][]int, len(before)+1)
+	for index := range common {
+		common[index] = make([]int, len(after)+1)
+	}
+
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var lines = []diffLine{}
+	var i, j = 0, 0
+
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			lines = append(lines, diffLine{' ', before[i]})
+			i++
+			j++
+		case j == len(after) || (i < len(before) && common[i+1][j] >= common[i][j+1]):
+			lines = append(lines, diffLine{'-', before[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', after[j]})
+			j++
+		}
+	}
+
+	return lines
+}
+
+func hunkHeader(lines []diffLine, from int, to int) string {
+	// lines start at 1
+	var beforeStart, afterStart = 1, 1
+	var beforeCount, afterCount = 0, 0
+
+	for index, line := range lines[:to] {
+		var inHunk = index >= from
+
+		if line.kind != '+' {
+			if inHunk {
+				beforeCount++
+			} else {
+				beforeStart++
+			}
+		}
+
+		if line.kind != '-' {
+			if inHunk {
+				afterCount++
+			} else {
+				afterStart++
+			}
+		}
+	}
+
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", beforeStart, beforeCount, afterStart, afterCount)
+}
+
+func splitLines(content string) []string {
+	if content == "" {
+		return []string{}
+	}
+
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
@@ -0,0 +1,52 @@
+package fileutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	var lines = diffLines([]string{"a", "b", "c", "d"}, []string{"a", "c", "e", "d"})
+
+	var result = []string{}
+	for _, line := range lines {
+		result = append(result, string(line.kind)+line.text)
+	}
+
+	if strings.Join(result, ",") != " a,-b, c,+e, d" {
+		t.Errorf("diffLines = %v", result)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	if diff := Diff("cart/state.js", "same\n", "same\n"); diff != "" {
+		t.Errorf("diff of equal files = %q", diff)
+	}
+
+	var before = "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	var after = "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n"
+
+	var expected = strings.Join([]string{
+		"--- a/cart/state.js",
+		"+++ b/cart/state.js",
+		"@@ -1,6 +1,6 @@",
+		" 1",
+		" 2",
+		"-3",
+		"+three",
+		" 4",
+		" 5",
+		" 6",
+		"@@ -9,4 +9,4 @@",
+		" 9",
+		" 10",
+		" 11",
+		"-12",
+		"+twelve",
+		"",
+	}, "\n")
+
+	if diff := Diff("cart/state.js", before, after); diff != expected {
+		t.Errorf("Diff =\n%s\nexpected\n%s", diff, expected)
+	}
+}
@@ -1,3 +0,0 @@
-module fileutil
-
-go 1.21.0
@@ -1,8 +1,10 @@
 package fileutil
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -65,6 +67,63 @@ func CopyDirectory(scrDir, dest string) error {
 	return nil
 }
 
+// SyncDirectory makes the destination a copy of the source directory, only
+// the files whose content differs are written and the files missing from
+// the source are removed
+func SyncDirectory(srcDir, dest string) error {
+	err := filepath.WalkDir(srcDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		var destPath = filepath.Join(dest, rel)
+		if current, err := os.ReadFile(destPath); err == nil && bytes.Equal(current, content) {
+			return nil
+		}
+
+		if err := CreateIfNotExists(filepath.Dir(destPath), 0755); err != nil {
+			return err
+		}
+
+		return os.WriteFile(destPath, content, 0644)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return filepath.WalkDir(dest, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dest, path)
+		if err != nil || Exists(filepath.Join(srcDir, rel)) {
+			return err
+		}
+
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+}
+
 func Copy(srcFile, dstFile string) error {
 	out, err := os.Create(dstFile)
 	if err != nil {
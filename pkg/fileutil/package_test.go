@@ -0,0 +1,67 @@
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSyncDirectory(t *testing.T) {
+	var src, dest = t.TempDir(), t.TempDir()
+
+	for path, content := range map[string]string{
+		"cart/index.js": "changed",
+		"user/index.js": "same",
+		"new/index.js":  "added",
+	} {
+		writeFile(t, filepath.Join(src, path), content)
+	}
+
+	for path, content := range map[string]string{
+		"cart/index.js":    "before",
+		"user/index.js":    "same",
+		"removed/index.js": "removed",
+		"user/old.js":      "removed",
+	} {
+		writeFile(t, filepath.Join(dest, path), content)
+	}
+
+	// the unchanged files are not written
+	var past = time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dest, "user/index.js"), past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SyncDirectory(src, dest); err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := ReadTree(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tree) != 3 || tree["cart/index.js"] != "changed" || tree["user/index.js"] != "same" || tree["new/index.js"] != "added" {
+		t.Errorf("destination synced as %v", tree)
+	}
+
+	if Exists(filepath.Join(dest, "removed")) {
+		t.Error("removed directory is kept")
+	}
+
+	info, err := os.Stat(filepath.Join(dest, "user/index.js"))
+	if err != nil || !info.ModTime().Equal(past) {
+		t.Errorf("unchanged file written again (%v)", err)
+	}
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,237 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fdbiondi/vuex-pinia-migration-tool/pkg/fileutil"
+	"github.com/fdbiondi/vuex-pinia-migration-tool/pkg/parser"
+)
+
+type (
+	Config       = parser.Config
+	Result       = parser.Result
+	ModuleResult = parser.ModuleResult
+	Diagnostic   = parser.Diagnostic
+)
+
+// Options of a migration
+type Options struct {
+	// vuex store directory
+	Source string
+	// directory of the pinia stores, the source is migrated in place when
+	// empty. Nuxt 2 stores are created in its stores directory
+	Destination string
+	// removes the destination of a previous run, otherwise it is kept as a
+	// versioned copy
+	RemoveDestination bool
+	Config            Config
+}
+
+// Migrate translates the vuex store of the source directory into pinia
+// stores, returning the outcome of each module
+func Migrate(ctx context.Context, options Options) (*Result, error) {
+	config, sourceDir, err := prepare(options)
+	if err != nil {
+		return nil, err
+	}
+
+	// the modules migrated by a previous run are skipped
+	if options.Destination == "" {
+		return parser.Migrate(ctx, sourceDir, config)
+	}
+
+	destDir, err := filepath.Abs(options.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	// the cache of the previous run is kept in the new destination
+	cache, _ := os.ReadFile(filepath.Join(storesDir(destDir, config), parser.CACHE_FILENAME))
+
+	if options.RemoveDestination {
+		err := os.RemoveAll(destDir)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if fileutil.Exists(destDir) {
+			// move old dir
+			err = fileutil.VersionDir(destDir)
+			if err != nil {
+				return nil, err
+			}
+
+			// create new dir
+			err = fileutil.CreateIfNotExists(destDir, 0755)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if config.Verbose && config.Logger != nil {
+		config.Logger.Printf("source path '%s'", sourceDir)
+		config.Logger.Printf("output path '%s'\n\n", destDir)
+	}
+
+	return migrateCopy(ctx, sourceDir, destDir, config, cache)
+}
+
+// Update migrates again the modules of the changed source files into the
+// destination of a previous run. The source is migrated in a temporary
+// directory where the other modules are restored from the cache, unless
+// the change affects them, and only the files which differ are written to
+// the destination
+func Update(ctx context.Context, options Options, changed []string) (*Result, error) {
+	config, sourceDir, err := prepare(options)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Destination == "" {
+		return nil, fmt.Errorf("the destination of the previous run is required")
+	}
+
+	destDir, err := filepath.Abs(options.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range changed {
+		if modulePath := parser.ModulePathOf(sourceDir, path, config); !slices.Contains(config.Changed, modulePath) {
+			config.Changed = append(config.Changed, modulePath)
+		}
+	}
+
+	cache, err := os.ReadFile(filepath.Join(storesDir(destDir, config), parser.CACHE_FILENAME))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	tempDir, err := os.MkdirTemp("", "vuex2pinia")
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.RemoveAll(tempDir)
+
+	// the output refers to the destination instead of the temporary directory
+	if logger := config.Logger; logger != nil {
+		config.Logger = log.New(destWriter{logger.Writer(), tempDir, destDir}, logger.Prefix(), logger.Flags())
+	}
+
+	result, err := migrateCopy(ctx, sourceDir, tempDir, config, cache)
+
+	if result != nil {
+		for index := range result.Diagnostics {
+			result.Diagnostics[index].File = strings.Replace(result.Diagnostics[index].File, tempDir, destDir, 1)
+		}
+
+		for _, module := range result.Modules {
+			for index := range module.Diagnostics {
+				module.Diagnostics[index].File = strings.Replace(module.Diagnostics[index].File, tempDir, destDir, 1)
+			}
+		}
+	}
+
+	if err != nil {
+		return result, err
+	}
+
+	return result, fileutil.SyncDirectory(tempDir, destDir)
+}
+
+// destWriter writes the output of a migration in a temporary directory with
+// the paths of the destination
+type destWriter struct {
+	out     io.Writer
+	tempDir string
+	destDir string
+}
+
+func (w destWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.out, strings.ReplaceAll(string(p), w.tempDir, w.destDir)); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// prepare returns the configuration of the migration and the absolute path
+// of the source directory
+func prepare(options Options) (Config, string, error) {
+	var config = options.Config
+
+	if err := validate(config); err != nil {
+		return config, "", err
+	}
+
+	sourceDir, err := filepath.Abs(options.Source)
+	if err != nil {
+		return config, "", err
+	}
+
+	if !fileutil.Exists(sourceDir) {
+		return config, "", fmt.Errorf("source directory '%s' does not exist", sourceDir)
+	}
+
+	return config, sourceDir, nil
+}
+
+// storesDir returns the directory of the stores in the destination,
+// @pinia/nuxt loads the stores from the stores directory
+func storesDir(destDir string, config Config) string {
+	if config.Framework == parser.NUXT2_FRAMEWORK {
+		return filepath.Join(destDir, "stores")
+	}
+
+	return destDir
+}
+
+// migrateCopy copies the source into the stores directory of the
+// destination with the cache of the previous run, then migrates it
+func migrateCopy(ctx context.Context, sourceDir string, destDir string, config Config, cache []byte) (*Result, error) {
+	var storesDir = storesDir(destDir, config)
+
+	err := os.MkdirAll(storesDir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fileutil.CopyDirectory(sourceDir, storesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if cache != nil && !config.NoCache {
+		err = os.WriteFile(filepath.Join(storesDir, parser.CACHE_FILENAME), cache, 0644)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return parser.Migrate(ctx, storesDir, config)
+}
+
+func validate(config Config) error {
+	if config.Lang != "" && config.Lang != parser.LANG_AUTO && config.Lang != parser.LANG_JS && config.Lang != parser.LANG_TS {
+		return fmt.Errorf("unsupported language '%s'", config.Lang)
+	}
+
+	if config.Framework != "" && config.Framework != parser.NUXT2_FRAMEWORK {
+		return fmt.Errorf("unsupported framework '%s'", config.Framework)
+	}
+
+	if config.Jobs < 0 {
+		return fmt.Errorf("jobs must be at least 1")
+	}
+
+	return nil
+}
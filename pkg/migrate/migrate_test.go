@@ -0,0 +1,72 @@
+package migrate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var cartState = "export default () => ({\n  items: [],\n});\n"
+
+func writeStore(t *testing.T, root string, files map[string]string) {
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Join(root, filepath.Dir(path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(root, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	var source, dest = t.TempDir(), filepath.Join(t.TempDir(), "stores")
+
+	writeStore(t, source, map[string]string{
+		"cart/state.js":   cartState,
+		"cart/actions.js": "export default {\n  load() {},\n};\n",
+		"user/state.js":   "export default () => ({\n  name: '',\n});\n",
+		"user/actions.js": "export default {\n  login() {},\n};\n",
+	})
+
+	var options = Options{Source: source, Destination: dest}
+	if _, err := Migrate(context.Background(), options); err != nil {
+		t.Fatal(err)
+	}
+
+	var past = time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dest, "user", "index.js"), past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	writeStore(t, source, map[string]string{"cart/state.js": "export default () => ({\n  items: [],\n  total: 0,\n});\n"})
+
+	result, err := Update(context.Background(), options, []string{filepath.Join(source, "cart", "state.js")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Modules) != 2 {
+		t.Errorf("modules reported as %+v", result.Modules)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dest, "cart", "state.js"))
+	if err != nil || string(content) != "export default () => ({\n  items: [],\n  total: 0,\n});\n" {
+		t.Errorf("cart state updated as %s (%v)", content, err)
+	}
+
+	// the files of the other modules are not written again
+	info, err := os.Stat(filepath.Join(dest, "user", "index.js"))
+	if err != nil || !info.ModTime().Equal(past) {
+		t.Errorf("user store written again (%v)", err)
+	}
+}
+
+func TestUpdateRequiresDestination(t *testing.T) {
+	if _, err := Update(context.Background(), Options{Source: t.TempDir()}, []string{}); err == nil {
+		t.Error("update without a destination completed")
+	}
+}
@@ -0,0 +1,295 @@
+package parser
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+var analyzePattern = map[string]*regexp.Regexp{
+	string("namespaced_call"): regexp.MustCompile(`\b(commit|dispatch)\(\s*('|")\w[\w-]*/`),
+	string("root_ref"):        regexp.MustCompile(`\b(rootState|rootGetters)\b`),
+	string("map_helper"):      regexp.MustCompile(`\bmap(State|Getters|Mutations|Actions)\(\s*('|")([\w/-]+)('|")`),
+	string("store_ref"):       regexp.MustCompile(`\$store\.(state\.(\w+)|getters\[('|")([\w-]+)/|commit\(\s*('|")([\w-]+)/|dispatch\(\s*('|")([\w-]+)/)`),
+	string("component_file"):  regexp.MustCompile(`\.(vue|js|ts|jsx|tsx)$`),
+}
+
+// vuex feature found in the store code, the weight is subtracted from the
+// score of the module for each use
+type vuexFeature struct {
+	name    string
+	pattern *regexp.Regexp
+	weight  int
+}
+
+var vuexFeatures = []vuexFeature{
+	{"Vue.set/delete", regexp.MustCompile(`\b(Vue\.|this\._vm\.\$)(set|delete)\(`), 0},
+	{"this._vm", regexp.MustCompile(`\bthis\._vm\b`), 5},
+	{"this.$store", regexp.MustCompile(`\bthis\.\$store\b`), 10},
+	{"store.watch/subscribe", regexp.MustCompile(`\.(watch|subscribe|subscribeAction)\(`), 10},
+	{"registerModule", regexp.MustCompile(`\b(registerModule|unregisterModule)\(`), 15},
+	{"hotUpdate", regexp.MustCompile(`\bhotUpdate\(`), 0},
+	{"nuxtServerInit", regexp.MustCompile(`\bnuxtServerInit\b`), 10},
+	{"vuex types", regexp.MustCompile(`\b(ActionTree|GetterTree|MutationTree|ActionContext)\b`), 0},
+	{"vuex-module-decorators", regexp.MustCompile(`\bVuexModule\b`), 5},
+	{"strict mode", regexp.MustCompile(`\bstrict:\s`), 0},
+}
+
+// vuex usage of a module
+type moduleReport struct {
+	path       string
+	namespaced bool
+	state      int
+	getters    int
+	mutations  int
+	actions    int
+	crossCalls int
+	dynamic    int
+	components int
+	features   map[string]int
+	score      int
+}
+
+// Analyze prints the vuex usage of each module of the store directory, the
+// components directory is searched for the modules used by components
+func Analyze(root string, components string) error {
+	var mg = newMigration(root, Config{})
+	mg.modules = mg.discoverModules()
+
+	var reports = []moduleReport{}
+
+	for modulePath, module := range mg.modules {
+		report, err := mg.analyzeModule(modulePath, module)
+		if err != nil {
+			return err
+		}
+
+		reports = append(reports, report)
+	}
+
+	if components != "" {
+		usage, err := mg.componentUsage(components)
+		if err != nil {
+			return err
+		}
+
+		for index := range reports {
+			reports[index].components = usage[reports[index].path]
+		}
+	}
+
+	sort.Slice(reports, func(i, j int) bool { return reports[i].path < reports[j].path })
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "MODULE\tNAMESPACED\tSTATE\tGETTERS\tMUTATIONS\tACTIONS\tCROSS-MODULE\tDYNAMIC\tCOMPONENTS\tSCORE\tFEATURES")
+
+	for _, report := range reports {
+		fmt.Fprintf(writer, "%s\t%t\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d%%\t%s\n", report.path, report.namespaced, report.state, report.getters, report.mutations, report.actions, report.crossCalls, report.dynamic, report.components, report.score, featureList(report.features))
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	mg.printRootReport()
+
+	return nil
+}
+
+// analyzeModule counts the definitions and the vuex features of a module
+func (mg *migration) analyzeModule(modulePath string, module storeModule) (moduleReport, error) {
+	var report = moduleReport{
+		path:       modulePath,
+		namespaced: module.namespaced,
+		getters:    len(module.getters),
+		features:   map[string]int{},
+	}
+
+	for _, name := range []string{"state", "getters", "mutations", "actions", "index"} {
+		for _, ext := range []string{".ts", ".js"} {
+			var path = filepath.Join(mg.root, modulePath, fmt.Sprint(name, ext))
+			if !fileExists(path) {
+				continue
+			}
+
+			if name == "state" {
+				_, _, fields, err := mg.readStateFactory(path)
+				if err != nil {
+					return report, err
+				}
+
+				report.state = len(fields)
+				continue
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return report, err
+			}
+
+			for _, line := range strings.Split(string(content), "\n") {
+				if name == "mutations" && modulePattern["function_name"].FindStringSubmatch(line) != nil {
+					report.mutations++
+				}
+
+				if name == "actions" && modulePattern["function_name"].FindStringSubmatch(line) != nil {
+					report.actions++
+				}
+
+				report.dynamic += dynamicCalls(line)
+
+				report.crossCalls += len(analyzePattern["namespaced_call"].FindAllString(line, -1))
+				report.crossCalls += len(analyzePattern["root_ref"].FindAllString(line, -1))
+
+				for _, feature := range vuexFeatures {
+					report.features[feature.name] += len(feature.pattern.FindAllString(line, -1))
+				}
+			}
+		}
+	}
+
+	report.score = migrationScore(report)
+
+	return report, nil
+}
+
+// dynamicCalls counts the commit and dispatch calls of a line whose target
+// is only known at runtime, template literals without interpolation are not
+// dynamic
+func dynamicCalls(line string) int {
+	var count = 0
+	var offset = 0
+
+	for {
+		loc := dynamicPattern["call"].FindStringIndex(line[offset:])
+		if loc == nil {
+			return count
+		}
+
+		args, end := splitCallArgs(line, offset+loc[1])
+		if end < 0 {
+			return count
+		}
+
+		offset = end
+
+		if len(args) == 0 || dynamicPattern["literal"].FindStringSubmatch(args[0]) != nil {
+			continue
+		}
+
+		if strings.HasPrefix(args[0], "`") && !strings.Contains(args[0], "${") {
+			continue
+		}
+
+		count++
+	}
+}
+
+// migrationScore returns the percentage of the module expected to be
+// migrated automatically
+func migrationScore(report moduleReport) int {
+	var score = 100 - report.dynamic*10 - report.crossCalls
+
+	for _, feature := range vuexFeatures {
+		score -= report.features[feature.name] * feature.weight
+	}
+
+	return max(score, 0)
+}
+
+func featureList(features map[string]int) string {
+	var names = []string{}
+
+	for _, feature := range vuexFeatures {
+		if count := features[feature.name]; count > 0 {
+			names = append(names, fmt.Sprintf("%s(%d)", feature.name, count))
+		}
+	}
+
+	if len(names) == 0 {
+		return "-"
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// componentUsage returns the number of component files using each module,
+// through the map helpers or the `$store` instance
+func (mg *migration) componentUsage(root string) (map[string]int, error) {
+	var usage = map[string]int{}
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || analyzePattern["component_file"].FindStringSubmatch(path) == nil {
+			return err
+		}
+
+		// the store is not a component
+		if rel, err := filepath.Rel(mg.root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		var used = map[string]bool{}
+
+		for _, match := range analyzePattern["map_helper"].FindAllStringSubmatch(string(content), -1) {
+			used[mg.resolveNamespace(match[3])] = true
+		}
+
+		for _, match := range analyzePattern["store_ref"].FindAllStringSubmatch(string(content), -1) {
+			for _, namespace := range []string{match[2], match[4], match[6], match[8]} {
+				if namespace != "" {
+					used[mg.resolveNamespace(namespace)] = true
+				}
+			}
+		}
+
+		for modulePath := range used {
+			usage[modulePath]++
+		}
+
+		return nil
+	})
+
+	return usage, err
+}
+
+// printRootReport prints the plugins and options of the root store, which
+// are migrated to the pinia bootstrap
+func (mg *migration) printRootReport() {
+	rootPath, lines, err := readRootStore(mg.root)
+	if err != nil || rootPath == "" {
+		return
+	}
+
+	var plugins = []string{}
+	for _, entry := range findPlugins(lines) {
+		name, _, _ := strings.Cut(entry, "(")
+		plugins = append(plugins, name)
+	}
+
+	var features = map[string]int{}
+
+	for _, line := range lines {
+		for _, feature := range vuexFeatures {
+			features[feature.name] += len(feature.pattern.FindAllString(line, -1))
+		}
+	}
+
+	fmt.Printf("\nroot store %s\n", rootPath)
+	fmt.Printf("  plugins: %d", len(plugins))
+
+	if len(plugins) > 0 {
+		fmt.Printf(" (%s)", strings.Join(plugins, ", "))
+	}
+
+	fmt.Printf("\n  features: %s\n", featureList(features))
+}
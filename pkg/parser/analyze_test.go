@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"maps"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnalyzeModule(t *testing.T) {
+	var root = t.TempDir()
+	writeModule(t, root, "cart", map[string]string{
+		"state.js":     "export default () => ({\n  items: [],\n  total: 0,\n});\n",
+		"getters.js":   cartModule["getters.js"],
+		"mutations.js": "export default {\n  add(state, item) {\n    state.items.push(item);\n  },\n  remove(state, index) {\n    Vue.delete(state.items, index);\n  },\n};\n",
+		"actions.js":   "export default {\n  add({ commit, rootState }, item) {\n    commit('add', item);\n    commit(rootState.mutation, item);\n    this.$store.dispatch('user/load', null, { root: true });\n  },\n};\n",
+	})
+
+	var mg = newMigration(root, Config{})
+	mg.modules = mg.discoverModules()
+
+	report, err := mg.analyzeModule("cart", mg.modules["cart"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.state != 2 || report.getters != 1 || report.mutations != 2 || report.actions != 1 {
+		t.Errorf("definitions counted as %+v", report)
+	}
+
+	// rootState twice, the user module once
+	if report.dynamic != 1 || report.crossCalls != 3 {
+		t.Errorf("calls counted as %d dynamic, %d cross module", report.dynamic, report.crossCalls)
+	}
+
+	if report.features["Vue.set/delete"] != 1 || report.features["this.$store"] != 1 {
+		t.Errorf("features counted as %v", report.features)
+	}
+
+	// 100 - 10 per dynamic call - 1 per cross module call - 10 for this.$store
+	if report.score != 77 {
+		t.Errorf("score = %d", report.score)
+	}
+}
+
+func TestDynamicCalls(t *testing.T) {
+	var tests = []struct {
+		line     string
+		expected int
+	}{
+		{"    commit('add', item);", 0},
+		{"    commit(rootState.mutation, item);", 1},
+		{"    dispatch(`user/${name}`, null, { root: true });", 1},
+		{"    dispatch(`user/load`, null, { root: true });", 0},
+		{"    this.api.commit(payload);", 0},
+		{"    commit(type); dispatch(action);", 2},
+	}
+
+	for _, test := range tests {
+		if count := dynamicCalls(test.line); count != test.expected {
+			t.Errorf("dynamicCalls(%q) = %d, expected %d", test.line, count, test.expected)
+		}
+	}
+}
+
+func TestMigrationScore(t *testing.T) {
+	if score := migrationScore(moduleReport{dynamic: 20}); score != 0 {
+		t.Errorf("score below zero: %d", score)
+	}
+}
+
+func TestComponentUsage(t *testing.T) {
+	var dir = t.TempDir()
+	var root = filepath.Join(dir, "store")
+
+	writeModule(t, root, "cart", cartModule)
+	writeModule(t, root, "user", cartModule)
+	writeModule(t, dir, "components", map[string]string{
+		"Cart.vue":   "computed: {\n  ...mapGetters('cart', ['count']),\n},\n",
+		"Header.vue": "this.$store.state.user.name;\nthis.$store.dispatch('cart/add', item);\n",
+		"README.md":  "mapGetters('user')",
+	})
+
+	var mg = newMigration(root, Config{})
+	mg.modules = mg.discoverModules()
+
+	usage, err := mg.componentUsage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !maps.Equal(usage, map[string]int{"cart": 2, "user": 1}) {
+		t.Errorf("components usage = %v", usage)
+	}
+}
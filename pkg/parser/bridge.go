@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// vuex module proxying to a migrated store
+const BRIDGE_FILENAME = "vuex-bridge"
+
+// isMigrated checks if the module at the path is part of the migration
+func (mg *migration) isMigrated(modulePath string) bool {
+	return len(mg.config.Modules) == 0 || slices.Contains(mg.config.Modules, modulePath)
+}
+
+// checkMigratedModules warns about unknown modules and about migrated
+// modules depending on modules that are still vuex modules
+func (mg *migration) checkMigratedModules() {
+	for _, modulePath := range mg.config.Modules {
+		if _, ok := mg.modules[modulePath]; !ok {
+			mg.addDiagnostic(mg.root, 0, "module %s not found", modulePath)
+		}
+	}
+
+	for modulePath := range mg.modules {
+		parent, _, ok := cutLast(modulePath, "/")
+		if ok && mg.isMigrated(parent) && !mg.isMigrated(modulePath) {
+			mg.addDiagnostic(filepath.Join(mg.root, modulePath), 0, "%s is still a vuex module, register it in the modules of the %s bridge", modulePath, parent)
+		}
+	}
+
+	graph, err := mg.buildGraph()
+	if err != nil {
+		return
+	}
+
+	for _, edge := range graph.Edges {
+		if mg.isMigrated(edge.From) && !mg.isMigrated(edge.To) {
+			mg.addDiagnostic(filepath.Join(mg.root, edge.From), 0, "%s is not migrated yet, the %s references to it must go through the vuex store", edge.To, edge.Kind)
+		}
+	}
+}
+
+// writeBridge creates the vuex module proxying the state, getters,
+// mutations and actions to the migrated store, so the vuex modules and
+// components not migrated yet keep working
+func (mg *migration) writeBridge(dir string, modulePath string, lang string) (string, error) {
+	var module = mg.modules[modulePath]
+	_, storeFn := storeNames(modulePath)
+
+	var params = "_, payload"
+	var target = "target"
+	if lang == LANG_TS {
+		params = "_: unknown, payload?: any"
+		target = "target: object"
+	}
+
+	var lines = []string{
+		"import { getActivePinia } from 'pinia';",
+		fmt.Sprintf("import { %s } from './index';", storeFn),
+		"",
+		"// vuex creates the state of its modules before pinia is installed, the",
+		"// store is resolved once the state is used",
+		fmt.Sprintf("const storeState = (%s) => (getActivePinia() ? %s().$state : target);", target, storeFn),
+		"const state = new Proxy({}, {",
+		"  get: (target, key) => Reflect.get(storeState(target), key),",
+		"  set: (target, key, value) => Reflect.set(storeState(target), key, value),",
+		"  has: (target, key) => Reflect.has(storeState(target), key),",
+		"});",
+		"",
+		fmt.Sprintf("// TODO remove once no vuex module or component uses the %s module", modulePath),
+		"export default {",
+		fmt.Sprintf("  namespaced: %t,", module.namespaced),
+		"  state: () => state,",
+	}
+
+	var getters = slices.Clone(module.getters)
+	var mutations = slices.Clone(module.mutations)
+	var actions = slices.DeleteFunc(slices.Clone(module.actions), func(name string) bool { return slices.Contains(mutations, name) })
+
+	sort.Strings(getters)
+	sort.Strings(mutations)
+	sort.Strings(actions)
+
+	if len(getters) > 0 {
+		lines = append(lines, "  getters: {")
+		for _, name := range getters {
+			lines = append(lines, fmt.Sprintf("    %s: () => %s().%s,", name, storeFn, name))
+		}
+		lines = append(lines, "  },")
+	}
+
+	if len(mutations) > 0 {
+		lines = append(lines, "  mutations: {")
+		for _, name := range mutations {
+			lines = append(lines, fmt.Sprintf("    %s(%s) {", name, params), fmt.Sprintf("      %s().%s(payload);", storeFn, name), "    },")
+		}
+		lines = append(lines, "  },")
+	}
+
+	if len(actions) > 0 {
+		lines = append(lines, "  actions: {")
+		for _, name := range actions {
+			lines = append(lines, fmt.Sprintf("    %s(%s) {", name, params), fmt.Sprintf("      return %s().%s(payload);", storeFn, name), "    },")
+		}
+		lines = append(lines, "  },")
+	}
+
+	lines = append(lines, "};", "")
+
+	var path = filepath.Join(dir, fmt.Sprint(BRIDGE_FILENAME, ".", lang))
+
+	return path, os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644)
+}
@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteBridge(t *testing.T) {
+	var dir = t.TempDir()
+	var mg = newMigration(dir, Config{Modules: []string{"cart"}})
+	mg.modules["cart"] = storeModule{
+		namespaced: true,
+		actions:    []string{"add", "load"},
+		mutations:  []string{"add"},
+		getters:    []string{"count"},
+	}
+
+	path, err := mg.writeBridge(dir, "cart", LANG_JS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if filepath.Base(path) != "vuex-bridge.js" {
+		t.Errorf("bridge written to %s", path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"getActivePinia() ? useCartStore().$state : target",
+		"  state: () => state,",
+		"    count: () => useCartStore().count,",
+		"      useCartStore().add(payload);",
+		"      return useCartStore().load(payload);",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("bridge does not contain %q:\n%s", expected, content)
+		}
+	}
+
+	// the store must not be used while vuex creates the state
+	if strings.Contains(string(content), "state: () => useCartStore()") {
+		t.Errorf("bridge state uses the store before pinia is installed:\n%s", content)
+	}
+}
+
+func TestMigrateBridgesSelectedModules(t *testing.T) {
+	var root = t.TempDir()
+	writeModule(t, root, "cart", cartModule)
+	writeModule(t, root, "user", cartModule)
+
+	result, err := Migrate(context.Background(), root, Config{NoCache: true, Modules: []string{"cart"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var statuses = map[string]string{}
+	for _, module := range result.Modules {
+		statuses[module.Path] = module.Status
+	}
+
+	if statuses["cart"] != MODULE_CREATED || statuses["user"] == MODULE_CREATED {
+		t.Errorf("modules reported as %v", statuses)
+	}
+
+	if !fileExists(filepath.Join(root, "cart", "vuex-bridge.js")) {
+		t.Errorf("cart bridge was not written")
+	}
+
+	for name, source := range cartModule {
+		content, err := os.ReadFile(filepath.Join(root, "user", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(content) != source {
+			t.Errorf("user/%s was migrated:\n%s", name, content)
+		}
+	}
+}
+
+func TestMigrateKeepsCallsToVuexModules(t *testing.T) {
+	var migrated = migratedModule(t, map[string]string{
+		"actions.js": "export default {\n  checkout({ dispatch, rootState }) {\n    dispatch('user/load', rootState.user.id, { root: true });\n  },\n};\n",
+	}, Config{Modules: []string{"cart"}})
+
+	assertContains(t, "actions.js", migrated["actions.js"],
+		"    // TODO user is still a vuex module, the references to it must go through the vuex store",
+		"    dispatch('user/load', rootState.user.id, { root: true });",
+	)
+
+	if strings.Contains(migrated["actions.js"], "useUserStore") {
+		t.Errorf("actions.js imports a store not migrated:\n%s", migrated["actions.js"])
+	}
+}
+
+func TestMigrateKeepsClassModulesNotSelected(t *testing.T) {
+	var root = t.TempDir()
+	writeModule(t, root, "cart", map[string]string{"index.ts": cartClassModule})
+	writeModule(t, root, "user", cartModule)
+
+	if _, err := Migrate(context.Background(), root, Config{NoCache: true, Modules: []string{"user"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, "cart", "index.ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != cartClassModule {
+		t.Errorf("cart/index.ts was migrated:\n%s", content)
+	}
+
+	if !fileExists(filepath.Join(root, "user", "vuex-bridge.js")) {
+		t.Errorf("user bridge was not written")
+	}
+}
@@ -0,0 +1,196 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// version of the translation, cached modules of other versions are migrated
+// again
+const VERSION = "0.2"
+
+// file of the store directory keeping the translated modules
+const CACHE_FILENAME = ".vuex2pinia-cache.json"
+
+// translation of a module, keyed by the module path in the cache
+type cacheEntry struct {
+	Key    string `json:"key"`
+	Status string `json:"status"`
+	// files of the module directory after the translation
+	Files       map[string]string `json:"files"`
+	Diagnostics []Diagnostic      `json:"diagnostics"`
+}
+
+type migrationCache struct {
+	Version string `json:"version"`
+	// hash of the options and the discovered store
+	Context string                `json:"context"`
+	Modules map[string]cacheEntry `json:"modules"`
+	// entries and context of the previous run
+	previous        map[string]cacheEntry
+	previousContext string
+	mutex           sync.Mutex
+}
+
+// loadCache reads the cache of the store directory, entries of another
+// version are dropped
+func (mg *migration) loadCache() *migrationCache {
+	var cache = &migrationCache{
+		Version:  VERSION,
+		Modules:  map[string]cacheEntry{},
+		previous: map[string]cacheEntry{},
+	}
+
+	var context = sha256.New()
+	fmt.Fprint(context, mg.config.Lang, mg.config.TypedState, mg.config.HMR, mg.config.Framework, mg.config.Modules, mg.modules, mg.persist, mg.cycles)
+	cache.Context = hex.EncodeToString(context.Sum(nil))
+
+	content, err := os.ReadFile(filepath.Join(mg.root, CACHE_FILENAME))
+	if err != nil {
+		return cache
+	}
+
+	var previous migrationCache
+	if err := json.Unmarshal(content, &previous); err != nil {
+		mg.addDiagnostic(filepath.Join(mg.root, CACHE_FILENAME), 0, "cache can not be read, every module is migrated again")
+		return cache
+	}
+
+	if previous.Version == VERSION && previous.Modules != nil {
+		cache.previous = previous.Modules
+		cache.previousContext = previous.Context
+	}
+
+	return cache
+}
+
+// save writes the entries of the modules translated by the current run
+func (c *migrationCache) save(root string) error {
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(root, CACHE_FILENAME), content, 0644)
+}
+
+// key returns the hash of the module files and the context of the run
+func (c *migrationCache) key(m *Module) (string, error) {
+	var hash = sha256.New()
+	fmt.Fprintln(hash, c.Context, m.mg.modulePathOf(m.files[0]))
+
+	for _, path := range m.files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintln(hash, filepath.Base(path), len(content))
+		hash.Write(content)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// translateCached reuses the previous translation of the module when its
+// key did not change, the module is then reported as unchanged. Otherwise
+// the module is translated and cached. The key is not computed for the
+// modules known to be unchanged
+func (m *Module) translateCached(cache *migrationCache) (string, error) {
+	var modulePath = m.mg.modulePathOf(m.files[0])
+
+	cache.mutex.Lock()
+	entry, ok := cache.previous[modulePath]
+	cache.mutex.Unlock()
+
+	var key = entry.Key
+	if !ok || cache.Context != cache.previousContext || !m.mg.isUnchanged(modulePath) {
+		var err error
+		if key, err = cache.key(m); err != nil {
+			return "", err
+		}
+	}
+
+	if ok && entry.Key == key {
+		if err := m.restoreEntry(modulePath, entry); err != nil {
+			return "", err
+		}
+
+		cache.add(modulePath, entry)
+
+		return MODULE_UNCHANGED, nil
+	}
+
+	status, err := m.translate()
+	if err != nil {
+		return "", err
+	}
+
+	entry = cacheEntry{Key: key, Status: status, Files: map[string]string{}, Diagnostics: []Diagnostic{}}
+
+	entries, err := os.ReadDir(m.path)
+	if err != nil {
+		return "", err
+	}
+
+	for _, e := range entries {
+		if e.IsDir() || e.Name() == CACHE_FILENAME {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(m.path, e.Name()))
+		if err != nil {
+			return "", err
+		}
+
+		entry.Files[e.Name()] = string(content)
+	}
+
+	for _, d := range m.mg.moduleDiagnostics(m.path) {
+		d.File, _ = filepath.Rel(m.mg.root, d.File)
+		entry.Diagnostics = append(entry.Diagnostics, d)
+	}
+
+	cache.add(modulePath, entry)
+
+	return status, nil
+}
+
+func (c *migrationCache) add(modulePath string, entry cacheEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.Modules[modulePath] = entry
+}
+
+// restoreEntry replaces the files of the module by the cached translation
+func (m *Module) restoreEntry(modulePath string, entry cacheEntry) error {
+	var mg = m.mg
+
+	for _, path := range m.files {
+		if _, ok := entry.Files[filepath.Base(path)]; ok {
+			continue
+		}
+
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+
+	for name, content := range entry.Files {
+		if err := os.WriteFile(filepath.Join(m.path, name), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+
+	for _, d := range entry.Diagnostics {
+		mg.addDiagnostic(filepath.Join(mg.root, d.File), d.Line, "%s", d.Message)
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateRestoresUnchangedModules(t *testing.T) {
+	var first = t.TempDir()
+	writeModule(t, first, "cart", cartModule)
+	writeModule(t, first, "user", cartModule)
+
+	if _, err := Migrate(context.Background(), first, Config{}); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := os.ReadFile(filepath.Join(first, CACHE_FILENAME))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the sources of both modules changed, only cart is reported
+	var second = t.TempDir()
+	var changed = map[string]string{}
+	for name, content := range cartModule {
+		changed[name] = strings.ReplaceAll(content, "item", "product")
+	}
+
+	writeModule(t, second, "cart", changed)
+	writeModule(t, second, "user", changed)
+
+	if err := os.WriteFile(filepath.Join(second, CACHE_FILENAME), cache, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Migrate(context.Background(), second, Config{Changed: []string{"cart"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for modulePath, translated := range map[string]bool{"cart": true, "user": false} {
+		content, err := os.ReadFile(filepath.Join(second, modulePath, "actions.js"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(string(content), "product") != translated {
+			t.Errorf("%s translated again: %t\n%s", modulePath, !translated, content)
+		}
+	}
+}
+
+func TestMigrateCachesTranslatedModules(t *testing.T) {
+	var first = t.TempDir()
+	writeModule(t, first, "cart", cartModule)
+	writeModule(t, first, "user", cartModule)
+
+	if _, err := Migrate(context.Background(), first, Config{}); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(first, CACHE_FILENAME))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the cached translation of the user module is marked to tell it from a
+	// new translation
+	var cache migrationCache
+	if err := json.Unmarshal(content, &cache); err != nil {
+		t.Fatal(err)
+	}
+
+	cache.Modules["user"].Files["actions.js"] = "// cached"
+	if content, err = json.Marshal(&cache); err != nil {
+		t.Fatal(err)
+	}
+
+	var changed = map[string]string{}
+	for name, content := range cartModule {
+		changed[name] = strings.ReplaceAll(content, "item", "product")
+	}
+
+	var tests = []struct {
+		name   string
+		config Config
+		cached map[string]bool
+	}{
+		{"same options", Config{}, map[string]bool{"cart": false, "user": true}},
+		{"other options", Config{HMR: true}, map[string]bool{"cart": false, "user": false}},
+		{"no cache", Config{NoCache: true}, map[string]bool{"cart": false, "user": false}},
+	}
+
+	for _, test := range tests {
+		var root = t.TempDir()
+		writeModule(t, root, "cart", changed)
+		writeModule(t, root, "user", cartModule)
+
+		if err := os.WriteFile(filepath.Join(root, CACHE_FILENAME), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := Migrate(context.Background(), root, test.config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the modules restored from the cache are not created again
+		for _, module := range result.Modules {
+			var expected = MODULE_CREATED
+			if test.cached[module.Path] {
+				expected = MODULE_UNCHANGED
+			}
+
+			if module.Status != expected {
+				t.Errorf("%s: %s reported as %s", test.name, module.Path, module.Status)
+			}
+		}
+
+		for modulePath, cached := range test.cached {
+			actions, err := os.ReadFile(filepath.Join(root, modulePath, "actions.js"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if (string(actions) == "// cached") != cached {
+				t.Errorf("%s: %s restored from the cache: %t\n%s", test.name, modulePath, !cached, actions)
+			}
+		}
+	}
+}
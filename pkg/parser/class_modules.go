@@ -0,0 +1,344 @@
+package parser
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var classPattern = map[string]*regexp.Regexp{
+	string("class_module"):      regexp.MustCompile(`\bextends\s+VuexModule\b`),
+	string("module_decorator"):  regexp.MustCompile(`^@Module(\((.*)\))?\s*$`),
+	string("module_name"):       regexp.MustCompile(`\bname:\s*('|")([\w/-]+)('|")`),
+	string("class"):             regexp.MustCompile(`^(export\s+)?(default\s+)?class\s+(\w+)\s+extends\s+VuexModule(<.*>)?\s*\{$`),
+	string("class_end"):         regexp.MustCompile(`^\}$`),
+	string("decorator"):         regexp.MustCompile(`^\s{2}@(Mutation|Action|MutationAction)(\((.*)\))?\s*$`),
+	string("field"):             regexp.MustCompile(`^\s{2}((public|private|protected|readonly)\s+)*(\w+)[?!]?(\:\s*([^=]+?))?\s*(=\s*(.*?))?;?$`),
+	string("getter"):            regexp.MustCompile(`^\s{2}((public|private|protected)\s+)?get\s+(\w+)\(\)(\:\s*.+?)?\s*\{$`),
+	string("method"):            regexp.MustCompile(`^\s{2}((public|private|protected)\s+)?(async\s+)?(\w+)\((.*)\)(\:\s*.+?)?\s*\{$`),
+	string("member_end"):        regexp.MustCompile(`^\s{2}\}$`),
+	string("context_call"):      regexp.MustCompile(`\bthis\.context\.(commit|dispatch)\(["'](.+?)["'],?\s?(.*)\)`),
+	string("context_prop"):      regexp.MustCompile(`\bthis\.context\.(getters|state)\.`),
+	string("context_root"):      regexp.MustCompile(`\bthis\.context\.(rootState|rootGetters)\b`),
+	string("decorators_import"): regexp.MustCompile(`^import\s.*\sfrom\s+('|")vuex-module-decorators('|");?$`),
+	string("get_module"):        regexp.MustCompile(`\bgetModule\((\w+)(,\s*[^)]+)?\)`),
+	string("default_import"):    regexp.MustCompile(`^import\s+(\w+)\s+from\s+(('|").+('|"));?$`),
+}
+
+// migrateClassModules translates the modules written with vuex-module-decorators
+// into stores, getModule calls are replaced by the store functions
+func (mg *migration) migrateClassModules() error {
+	// store functions keyed by the module class name
+	var storeFns = map[string]string{}
+	var files = []string{}
+
+	err := filepath.WalkDir(mg.root, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && isOriginalsDir(entry) {
+			return filepath.SkipDir
+		}
+
+		if err != nil || entry.IsDir() || !(strings.HasSuffix(path, ".ts") || strings.HasSuffix(path, ".js")) {
+			return err
+		}
+
+		files = append(files, path)
+
+		// the modules not migrated are kept as vuex modules
+		if !mg.isMigrated(mg.sourceModulePath(path)) {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil || classPattern["class_module"].FindStringSubmatch(string(content)) == nil {
+			return err
+		}
+
+		lines, className, storeFn := mg.translateClassModule(strings.Split(string(content), "\n"), path)
+		if className == "" {
+			mg.addDiagnostic(path, 0, "vuex module class could not be translated")
+			return nil
+		}
+
+		storeFns[className] = storeFn
+
+		mg.verbosef("Created %s store from class %s", storeFn, className)
+
+		return os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644)
+	})
+
+	if err != nil || len(storeFns) == 0 {
+		return err
+	}
+
+	for _, path := range files {
+		if err := mg.replaceGetModule(path, storeFns); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// translateClassModule returns the lines of the store defined from the class,
+// the class name and the store function name
+func (mg *migration) translateClassModule(lines []string, filename string) ([]string, string, string) {
+	var result = []string{"import { defineStore } from 'pinia';"}
+	var state, getters, actions = []string{}, []string{}, []string{}
+	var className, storeName string
+	var decorator []string
+	var stores = crossStores{mg: mg}
+	var typed = strings.HasSuffix(filename, ".ts")
+
+	for index := 0; index < len(lines); index++ {
+		line := lines[index]
+
+		if classPattern["decorators_import"].FindStringSubmatch(line) != nil {
+			continue
+		}
+
+		if match := classPattern["module_decorator"].FindStringSubmatch(line); match != nil {
+			if name := classPattern["module_name"].FindStringSubmatch(match[2]); name != nil {
+				storeName = name[2]
+			}
+
+			continue
+		}
+
+		match := classPattern["class"].FindStringSubmatch(line)
+		if match == nil {
+			result = append(result, line)
+			continue
+		}
+
+		className = match[3]
+		if storeName == "" {
+			storeName = strings.TrimSuffix(strings.TrimSuffix(className, "Module"), "Store")
+			storeName = fmt.Sprint(strings.ToLower(storeName[:1]), storeName[1:])
+		}
+
+		// class members
+		for index++; index < len(lines) && classPattern["class_end"].FindStringSubmatch(lines[index]) == nil; index++ {
+			line = lines[index]
+
+			if match := classPattern["decorator"].FindStringSubmatch(line); match != nil {
+				decorator = match
+
+				if match[1] == "MutationAction" || strings.Contains(match[3], "commit:") {
+					mg.addDiagnostic(filename, index+1, "@%s%s returns values committed by vuex, the action must update the state by hand", match[1], match[2])
+				}
+
+				continue
+			}
+
+			if match := classPattern["getter"].FindStringSubmatch(line); match != nil {
+				body, end := memberBody(lines, index)
+				index = end
+
+				getters = append(getters, fmt.Sprintf("    %s()%s {", match[3], match[4]))
+				getters = append(getters, indentLines(body, "  ")...)
+				getters = append(getters, "    },")
+
+				continue
+			}
+
+			if match := classPattern["method"].FindStringSubmatch(line); match != nil {
+				body, end := memberBody(lines, index)
+				index = end
+
+				if decorator == nil {
+					mg.addDiagnostic(filename, index+1, "method %s is not a mutation nor an action, it is added to the actions", match[4])
+				}
+
+				stores.reset()
+
+				var instances = []string{}
+				for bodyIndex, bodyLine := range body {
+					var defLines []string
+					body[bodyIndex], defLines = mg.translateClassMember(bodyLine, &stores)
+					instances = append(instances, defLines...)
+				}
+
+				actions = append(actions, fmt.Sprintf("    %s%s(%s)%s {", match[3], match[4], match[5], match[6]))
+				actions = append(actions, indentLines(instances, "  ")...)
+				actions = append(actions, indentLines(body, "  ")...)
+				actions = append(actions, "    },")
+				decorator = nil
+
+				continue
+			}
+
+			if match := classPattern["field"].FindStringSubmatch(line); match != nil && strings.TrimSpace(line) != "" {
+				var value = strings.TrimSpace(match[7])
+				var fieldType = strings.TrimSpace(match[5])
+
+				// multiline initial value
+				for balance := bracketBalance(value); balance > 0 && index+1 < len(lines); {
+					index++
+					balance += bracketBalance(lines[index])
+					value = fmt.Sprintf("%s\n  %s", value, strings.TrimSuffix(lines[index], ";"))
+				}
+
+				if value == "" {
+					value = "undefined"
+					if fieldType != "" {
+						fieldType = fmt.Sprintf("%s | undefined", fieldType)
+					}
+
+					mg.addDiagnostic(filename, index+1, "state %s has no initial value", match[3])
+				}
+
+				if typed && fieldType != "" {
+					value = fmt.Sprintf("%s as %s", value, fieldType)
+				}
+
+				state = append(state, fmt.Sprintf("    %s: %s,", match[3], value))
+
+				continue
+			}
+
+			if strings.TrimSpace(line) != "" {
+				result = append(result, line)
+			}
+		}
+
+		_, storeFn := storeNames(storeName)
+
+		result = append(result, fmt.Sprintf("export const %s = defineStore('%s', {", storeFn, path.Base(storeName)))
+		result = append(result, "  state: () => ({")
+		result = append(result, state...)
+		result = append(result, "  }),")
+
+		if len(getters) > 0 {
+			result = append(result, "  getters: {")
+			result = append(result, getters...)
+			result = append(result, "  },")
+		}
+
+		if len(actions) > 0 {
+			result = append(result, "  actions: {")
+			result = append(result, actions...)
+			result = append(result, "  },")
+		}
+
+		result = append(result, "});")
+
+		if mg.config.HMR {
+			result[0] = "import { acceptHMRUpdate, defineStore } from 'pinia';"
+			result = append(result, hmrLines(storeFn)...)
+		}
+
+		if match[2] != "" {
+			result = append(result, "", fmt.Sprintf("export default %s;", storeFn))
+		}
+	}
+
+	if className == "" {
+		return lines, "", ""
+	}
+
+	appendImports(&result, &stores.imports)
+
+	_, storeFn := storeNames(storeName)
+
+	return result, className, storeFn
+}
+
+// memberBody returns the lines of a class member and the index of its
+// closing line
+func memberBody(lines []string, index int) ([]string, int) {
+	var body = []string{}
+
+	for index++; index < len(lines) && classPattern["member_end"].FindStringSubmatch(lines[index]) == nil; index++ {
+		body = append(body, lines[index])
+	}
+
+	return body, index
+}
+
+// translateClassMember rewrites the uses of the vuex context inside a class
+// method, returning the lines instantiating other stores
+func (mg *migration) translateClassMember(line string, stores *crossStores) (string, []string) {
+	var defLines = []string{}
+
+	if match := classPattern["context_call"].FindStringSubmatch(line); match != nil {
+		if fn := strings.Split(match[2], "/"); len(fn) > 1 && strings.Contains(match[3], "root: true") {
+			args := strings.Replace(match[3], ", { root: true }", "", 1)
+			modulePath := mg.resolveNamespace(strings.Join(fn[:len(fn)-1], "/"))
+
+			if !mg.isMigrated(modulePath) {
+				// the call goes through the vuex store
+				if defLine := stores.keepVuex(modulePath); defLine != "" {
+					defLines = append(defLines, defLine)
+				}
+
+				return line, defLines
+			}
+
+			storeName, defLine := stores.use(modulePath)
+			if defLine != "" {
+				defLines = append(defLines, defLine)
+			}
+
+			line = classPattern["context_call"].ReplaceAllString(line, fmt.Sprintf("%s.%s(%s)", storeName, fn[len(fn)-1], args))
+		} else {
+			line = classPattern["context_call"].ReplaceAllString(line, "this.$2($3)")
+		}
+	}
+
+	line = classPattern["context_prop"].ReplaceAllString(line, "this.")
+	line = classPattern["context_root"].ReplaceAllString(line, "$1")
+
+	line, rootDefLines := stores.replaceRootRefs(line)
+
+	return line, append(defLines, rootDefLines...)
+}
+
+// replaceGetModule replaces the getModule calls and the imports of the
+// translated module classes with their store functions
+func (mg *migration) replaceGetModule(path string, storeFns map[string]string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var lines = strings.Split(string(content), "\n")
+	var changed = false
+
+	for index, line := range lines {
+		var updated = line
+
+		if match := classPattern["default_import"].FindStringSubmatch(line); match != nil {
+			if storeFn, ok := storeFns[match[1]]; ok {
+				updated = fmt.Sprintf("import { %s } from %s;", storeFn, match[2])
+			}
+		}
+
+		updated = classPattern["get_module"].ReplaceAllStringFunc(updated, func(call string) string {
+			match := classPattern["get_module"].FindStringSubmatch(call)
+			if storeFn, ok := storeFns[match[1]]; ok {
+				return fmt.Sprintf("%s()", storeFn)
+			}
+
+			return call
+		})
+
+		if updated != line {
+			if classPattern["get_module"].FindStringSubmatch(line) != nil && !strings.HasPrefix(line, " ") {
+				mg.addDiagnostic(path, index+1, "stores must not be used before pinia is installed, move the store call inside a function")
+			}
+
+			lines[index] = updated
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644)
+}
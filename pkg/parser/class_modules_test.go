@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var cartClassModule = strings.Join([]string{
+	"import { Module, VuexModule, Mutation, Action } from 'vuex-module-decorators';",
+	"import { Item } from '@/types';",
+	"",
+	"@Module({ namespaced: true, name: 'cart' })",
+	"export default class CartModule extends VuexModule {",
+	"  items: Item[] = [];",
+	"  total = 0;",
+	"  user!: string;",
+	"",
+	"  get count(): number {",
+	"    return this.items.length;",
+	"  }",
+	"",
+	"  @Mutation",
+	"  SET_ITEMS(items: Item[]) {",
+	"    this.items = items;",
+	"  }",
+	"",
+	"  @Action({ rawError: true })",
+	"  async load(id: string) {",
+	"    const items = await api.get(id);",
+	"    this.context.commit('SET_ITEMS', items);",
+	"  }",
+	"",
+	"  @Action",
+	"  clear() {",
+	"    this.context.dispatch('load', '');",
+	"  }",
+	"}",
+	"",
+}, "\n")
+
+func TestMigrateClassModules(t *testing.T) {
+	var root = t.TempDir()
+	writeModule(t, root, "modules", map[string]string{"cart.ts": cartClassModule})
+
+	if _, err := Migrate(context.Background(), root, Config{NoCache: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, "modules", "cart.ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertContains(t, "cart.ts", string(content),
+		"import { defineStore } from 'pinia';",
+		"export const useCartStore = defineStore('cart', {",
+		"    items: [] as Item[],\n    total: 0,\n    user: undefined as string | undefined,",
+		"  getters: {\n    count(): number {\n      return this.items.length;\n    },\n  },",
+		"    async load(id: string) {\n      const items = await api.get(id);\n      this.SET_ITEMS(items);\n    },",
+		"    clear() {\n      this.load('');\n    },",
+	)
+
+	if strings.Contains(string(content), "vuex-module-decorators") {
+		t.Errorf("cart.ts keeps the decorators import:\n%s", content)
+	}
+}
+
+func TestMigrateReportsClassModulesCreated(t *testing.T) {
+	var root = t.TempDir()
+	writeModule(t, root, "cart", map[string]string{"index.ts": cartClassModule})
+
+	// the store translated from the class is created by the first run only
+	for _, expected := range []string{MODULE_CREATED, MODULE_SKIPPED} {
+		result, err := Migrate(context.Background(), root, Config{NoCache: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result.Modules) != 1 || result.Modules[0].Status != expected {
+			t.Errorf("modules reported as %+v, expected cart %s", result.Modules, expected)
+		}
+	}
+}
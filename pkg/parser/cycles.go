@@ -0,0 +1,169 @@
+package parser
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+var cyclePattern = map[string]*regexp.Regexp{
+	string("top_level_instance"): regexp.MustCompile(`^(export\s+)?(const|let)\s+(\w+)\s*=\s*(use\w+Store)\(\);?$`),
+	string("instance"):           regexp.MustCompile(`^\s*(const|let)\s+(\w+)\s*=\s*(use\w+Store)\(\);?$`),
+}
+
+// findCycles returns the groups of modules depending on each other, using
+// the strongly connected components of the graph
+func findCycles(graph StoreGraph) [][]string {
+	var edges = map[string][]string{}
+	for _, edge := range graph.Edges {
+		if !slices.Contains(edges[edge.From], edge.To) {
+			edges[edge.From] = append(edges[edge.From], edge.To)
+		}
+	}
+
+	var index = 0
+	var indexes = map[string]int{}
+	var lowLinks = map[string]int{}
+	var stack = []string{}
+	var cycles = [][]string{}
+
+	var connect func(module string)
+	connect = func(module string) {
+		indexes[module] = index
+		lowLinks[module] = index
+		index++
+		stack = append(stack, module)
+
+		for _, next := range edges[module] {
+			if _, visited := indexes[next]; !visited {
+				connect(next)
+				lowLinks[module] = min(lowLinks[module], lowLinks[next])
+			} else if slices.Contains(stack, next) {
+				lowLinks[module] = min(lowLinks[module], indexes[next])
+			}
+		}
+
+		if lowLinks[module] != indexes[module] {
+			return
+		}
+
+		var component = []string{}
+		for {
+			var last = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			component = append(component, last)
+
+			if last == module {
+				break
+			}
+		}
+
+		if len(component) > 1 {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, module := range graph.Modules {
+		if _, visited := indexes[module]; !visited {
+			connect(module)
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+
+	return cycles
+}
+
+// detectCycles warns about the stores depending on each other
+func (mg *migration) detectCycles() [][]string {
+	graph, err := mg.buildGraph()
+	if err != nil {
+		return [][]string{}
+	}
+
+	var cycles = findCycles(graph)
+
+	for _, cycle := range cycles {
+		mg.addDiagnostic(filepath.Join(mg.root, cycle[0]), 0, "circular dependency between the stores %s, they are instantiated inside functions", strings.Join(cycle, ", "))
+	}
+
+	return cycles
+}
+
+// lazyStoreInstances replaces the stores instantiated at the top level of
+// the files of the modules in a cycle with calls inside the functions, the
+// stores of a cycle must be instantiated inside functions
+func (mg *migration) lazyStoreInstances() error {
+	for _, cycle := range mg.cycles {
+		for _, modulePath := range cycle {
+			var dir = filepath.Join(mg.root, modulePath)
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				continue
+			}
+
+			for _, entry := range entries {
+				if err := mg.lazyFileInstances(filepath.Join(dir, entry.Name()), entry); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (mg *migration) lazyFileInstances(path string, entry fs.DirEntry) error {
+	if entry.IsDir() || !(strings.HasSuffix(path, ".ts") || strings.HasSuffix(path, ".js")) {
+		return nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var lines = strings.Split(string(content), "\n")
+	var instances = map[string]string{}
+	var result = []string{}
+
+	for index := 0; index < len(lines); index++ {
+		var line = lines[index]
+
+		if match := cyclePattern["top_level_instance"].FindStringSubmatch(line); match != nil && match[1] == "" {
+			instances[match[3]] = match[4]
+			mg.addDiagnostic(path, index+1, "%s is instantiated when the module is loaded, it is called inside the functions instead", match[3])
+
+			index = skipBlankLine(result, lines, index)
+			continue
+		}
+
+		result = append(result, line)
+	}
+
+	if len(instances) == 0 {
+		return nil
+	}
+
+	for index, line := range result {
+		for name, storeFn := range instances {
+			// the instances created inside the functions by the translation
+			if match := cyclePattern["instance"].FindStringSubmatch(line); match != nil && match[2] == name && match[3] == storeFn {
+				continue
+			}
+
+			line = wordPattern(name).ReplaceAllString(line, fmt.Sprintf("${1}%s()", storeFn))
+		}
+
+		result[index] = line
+	}
+
+	return os.WriteFile(path, []byte(strings.Join(result, "\n")), 0644)
+}
@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindCycles(t *testing.T) {
+	var graph = StoreGraph{
+		Modules: []string{"a", "b", "c", "d", "e"},
+		Edges: []StoreEdge{
+			{From: "a", To: "b"},
+			{From: "b", To: "c"},
+			{From: "c", To: "a"},
+			{From: "d", To: "a"},
+			{From: "d", To: "e"},
+			{From: "e", To: "d"},
+		},
+	}
+
+	if cycles := findCycles(graph); !reflect.DeepEqual(cycles, [][]string{{"a", "b", "c"}, {"d", "e"}}) {
+		t.Errorf("findCycles = %v", cycles)
+	}
+}
+
+func TestDetectCycles(t *testing.T) {
+	var mg = cyclicStore(t)
+
+	if cycles := mg.detectCycles(); !reflect.DeepEqual(cycles, [][]string{{"cart", "user"}}) {
+		t.Errorf("detectCycles = %v", cycles)
+	}
+
+	if len(mg.diagnostics) != 1 {
+		t.Errorf("diagnostics = %v", mg.diagnostics)
+	}
+}
+
+func TestLazyFileInstances(t *testing.T) {
+	var tests = []struct {
+		source   string
+		expected string
+	}{
+		{
+			"import { useUserStore } from '~/stores/user';\n\nconst userStore = useUserStore();\n\nexport default {\n  checkout() {\n    userStore.load();\n    return this.userStore;\n  },\n};\n",
+			"import { useUserStore } from '~/stores/user';\n\nexport default {\n  checkout() {\n    useUserStore().load();\n    return this.userStore;\n  },\n};\n",
+		},
+		// the instance created inside the function by the translation
+		{
+			"import { useUserStore } from '~/stores/user';\n\nconst userStore = useUserStore();\n\nexport default {\n  checkout() {\n    const userStore = useUserStore()\n    userStore.load();\n  },\n};\n",
+			"import { useUserStore } from '~/stores/user';\n\nexport default {\n  checkout() {\n    const userStore = useUserStore()\n    useUserStore().load();\n  },\n};\n",
+		},
+	}
+
+	for _, test := range tests {
+		var dir = t.TempDir()
+		var path = filepath.Join(dir, "actions.js")
+
+		if err := os.WriteFile(path, []byte(test.source), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := newMigration(dir, Config{}).lazyFileInstances(path, entries[0]); err != nil {
+			t.Fatal(err)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(content) != test.expected {
+			t.Errorf("instances moved as\n%s", content)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Diagnostic describes code that could not be translated automatically and
+// needs to be reviewed by hand after the migration.
+type Diagnostic struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	if d.Line == 0 {
+		return fmt.Sprintf("%s: %s", d.File, d.Message)
+	}
+
+	return fmt.Sprintf("%s:%d: %s", d.File, d.Line, d.Message)
+}
+
+func (mg *migration) addDiagnostic(file string, line int, format string, a ...any) {
+	mg.diagnosticsMutex.Lock()
+	defer mg.diagnosticsMutex.Unlock()
+
+	mg.diagnostics = append(mg.diagnostics, Diagnostic{
+		File:    file,
+		Line:    line,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+// printDiagnostics outputs the diagnostics collected, which are added to
+// the result of the migration, and clears them.
+func (mg *migration) printDiagnostics() {
+	mg.diagnosticsMutex.Lock()
+	defer mg.diagnosticsMutex.Unlock()
+
+	for _, d := range mg.diagnostics {
+		mg.printf("warning: %s", d)
+	}
+
+	mg.result.Diagnostics = append(mg.result.Diagnostics, mg.diagnostics...)
+	mg.diagnostics = []Diagnostic{}
+}
+
+// printModuleDiagnostics outputs the diagnostics of the files of a module
+// directory, clears them and returns them.
+func (mg *migration) printModuleDiagnostics(dir string) []Diagnostic {
+	mg.diagnosticsMutex.Lock()
+	defer mg.diagnosticsMutex.Unlock()
+
+	var kept = []Diagnostic{}
+	var printed = []Diagnostic{}
+
+	for _, d := range mg.diagnostics {
+		if filepath.Dir(d.File) == dir {
+			mg.printf("warning: %s", d)
+			printed = append(printed, d)
+		} else {
+			kept = append(kept, d)
+		}
+	}
+
+	mg.diagnostics = kept
+
+	return printed
+}
+
+// moduleDiagnostics returns the diagnostics of the files of a module directory
+func (mg *migration) moduleDiagnostics(dir string) []Diagnostic {
+	mg.diagnosticsMutex.Lock()
+	defer mg.diagnosticsMutex.Unlock()
+
+	var result = []Diagnostic{}
+
+	for _, d := range mg.diagnostics {
+		if filepath.Dir(d.File) == dir {
+			result = append(result, d)
+		}
+	}
+
+	return result
+}
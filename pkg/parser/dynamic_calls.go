@@ -0,0 +1,191 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var dynamicPattern = map[string]*regexp.Regexp{
+	string("call"):               regexp.MustCompile(`(^|[^\w.$])(commit|dispatch)\(`),
+	string("literal"):            regexp.MustCompile(`^("|')[^"']*("|')$`),
+	string("namespaced"):         regexp.MustCompile("^`(.*)/([^/]*)`$"),
+	string("interpolation"):      regexp.MustCompile(`^\$\{(.+)\}$`),
+	string("identifier"):         regexp.MustCompile(`^\w+$`),
+	string("namespaced_literal"): regexp.MustCompile(`("|')\w+/[\w/]+("|')`),
+}
+
+// type of the keys used to call actions by name
+const ACTION_NAME_TYPE = "ActionName"
+
+// translateDynamicCalls rewrites commit and dispatch calls whose target is
+// not a string literal. It returns the lines instantiating the stores used,
+// whether the actions are called by name and the targets not translated
+func translateDynamicCalls(line string, stores *crossStores, typed bool) (string, []string, bool, []string) {
+	var defLines = []string{}
+	var untranslated = []string{}
+	var byName = false
+	var offset = 0
+
+	for {
+		loc := dynamicPattern["call"].FindStringSubmatchIndex(line[offset:])
+		if loc == nil {
+			break
+		}
+
+		// the match starts with the character before the call
+		var start = offset + loc[4]
+
+		args, end := splitCallArgs(line, offset+loc[1])
+		if end < 0 {
+			break
+		}
+
+		offset = end
+
+		if len(args) == 0 || dynamicPattern["literal"].FindStringSubmatch(args[0]) != nil {
+			continue
+		}
+
+		var target = args[0]
+		var payload = []string{}
+
+		for _, arg := range args[1:] {
+			if !strings.Contains(arg, "root: true") {
+				payload = append(payload, arg)
+			}
+		}
+
+		var call string
+
+		if match := dynamicPattern["namespaced"].FindStringSubmatch(target); match != nil {
+			var namespace, name = match[1], match[2]
+
+			// the calls to the modules not migrated go through the vuex store
+			if !strings.Contains(namespace, "${") && stores.mg.isMigrated(namespace) {
+				storeName, defLine := stores.use(namespace)
+				if defLine != "" {
+					defLines = append(defLines, defLine)
+				}
+
+				if interpolation := dynamicPattern["interpolation"].FindStringSubmatch(name); interpolation != nil {
+					call = fmt.Sprintf("%s[%s](%s)", storeName, interpolation[1], strings.Join(payload, ", "))
+
+					if typed {
+						untranslated = append(untranslated, target)
+					}
+				} else if !strings.Contains(name, "${") {
+					call = fmt.Sprintf("%s.%s(%s)", storeName, name, strings.Join(payload, ", "))
+				}
+			} else if interpolation := dynamicPattern["interpolation"].FindStringSubmatch(namespace); interpolation != nil && dynamicPattern["identifier"].FindStringSubmatch(name) != nil {
+				// look up the store by namespace
+				tableName, defLine := storesTable(stores, name)
+				if defLine != "" {
+					defLines = append(defLines, defLine)
+				}
+
+				var key = interpolation[1]
+				if typed {
+					key = fmt.Sprintf("%s as keyof typeof %s", key, tableName)
+				}
+
+				if tableName != "" {
+					call = fmt.Sprintf("%s[%s]().%s(%s)", tableName, key, name, strings.Join(payload, ", "))
+				}
+			}
+		} else if dynamicPattern["namespaced_literal"].FindStringSubmatch(target) == nil {
+			// action of the current store
+			var key = target
+			if typed {
+				key = fmt.Sprintf("(%s) as %s", target, ACTION_NAME_TYPE)
+			}
+
+			call = fmt.Sprintf("this[%s](%s)", key, strings.Join(payload, ", "))
+			byName = true
+		}
+
+		if call == "" {
+			untranslated = append(untranslated, target)
+			continue
+		}
+
+		line = line[:start] + call + line[end:]
+		offset = start + len(call)
+	}
+
+	return line, defLines, byName, untranslated
+}
+
+// storesTable returns the name of the table with the stores defining the
+// action, and the line declaring it when it is first used in the function
+func storesTable(stores *crossStores, name string) (string, string) {
+	var modules = stores.mg.modulesDefining(name)
+	var tableName = fmt.Sprintf("%sStores", name)
+
+	// the stores of the modules not migrated do not exist
+	if len(modules) == 0 || slices.ContainsFunc(modules, func(modulePath string) bool { return !stores.mg.isMigrated(modulePath) }) {
+		return "", ""
+	}
+
+	if slices.Contains(stores.instantiated, tableName) {
+		return tableName, ""
+	}
+
+	var entries = []string{}
+
+	for _, modulePath := range modules {
+		_, storeFn := stores.importStore(modulePath)
+		entries = append(entries, fmt.Sprintf("'%s': %s", modulePath, storeFn))
+	}
+
+	stores.instantiated = append(stores.instantiated, tableName)
+
+	return tableName, fmt.Sprintf("    const %s = { %s }", tableName, strings.Join(entries, ", "))
+}
+
+// splitCallArgs returns the arguments of the call starting at the given
+// index, and the index after the closing parenthesis, or -1 when the call
+// does not end in the line
+func splitCallArgs(line string, start int) ([]string, int) {
+	var args = []string{}
+	var depth = 0
+	var quote rune = 0
+	var argStart = start
+
+	for index, char := range line[start:] {
+		var position = start + index
+
+		if quote != 0 {
+			if char == quote {
+				quote = 0
+			}
+
+			continue
+		}
+
+		switch char {
+		case '\'', '"', '`':
+			quote = char
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth == 0 {
+				if arg := strings.TrimSpace(line[argStart:position]); arg != "" {
+					args = append(args, arg)
+				}
+
+				return args, position + 1
+			}
+
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(line[argStart:position]))
+				argStart = position + 1
+			}
+		}
+	}
+
+	return args, -1
+}
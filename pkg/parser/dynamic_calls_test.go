@@ -0,0 +1,27 @@
+package parser
+
+import "testing"
+
+func TestTranslateDynamicCalls(t *testing.T) {
+	var tests = []struct {
+		line     string
+		typed    bool
+		expected string
+	}{
+		{"    commit(name, payload)", false, "    this[name](payload)"},
+		{"    dispatch(name, payload)", true, "    this[(name) as ActionName](payload)"},
+		{"    commit('remove', 0)", false, "    commit('remove', 0)"},
+		{"    tx.commit(() => commit('remove', 0))", false, "    tx.commit(() => commit('remove', 0))"},
+		{"    tx.commit(name)", false, "    tx.commit(name)"},
+		{"    this.$store.dispatch(name)", false, "    this.$store.dispatch(name)"},
+		{"    await dispatch(`cart/${name}`, item)", false, "    await cartStore[name](item)"},
+	}
+
+	for _, test := range tests {
+		var stores = crossStores{mg: newMigration("", Config{})}
+
+		if result, _, _, _ := translateDynamicCalls(test.line, &stores, test.typed); result != test.expected {
+			t.Errorf("translateDynamicCalls(%q) = %q, expected %q", test.line, result, test.expected)
+		}
+	}
+}
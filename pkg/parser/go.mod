@@ -1,15 +0,0 @@
-module parser
-
-go 1.21.0
-
-require github.com/tdewolff/parse v2.3.4+incompatible
-
-require (
-	github.com/robertkrimen/otto v0.2.1 // indirect
-	github.com/tdewolff/minify v2.3.6+incompatible // indirect
-	github.com/tdewolff/minify/v2 v2.12.9 // indirect
-	github.com/tdewolff/parse/v2 v2.6.8 // indirect
-	github.com/tdewolff/test v1.0.9 // indirect
-	golang.org/x/text v0.4.0 // indirect
-	gopkg.in/sourcemap.v1 v1.0.5 // indirect
-)
@@ -0,0 +1,248 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var graphPattern = map[string]*regexp.Regexp{
+	string("call"):    regexp.MustCompile(`\b(commit|dispatch)\(\s*('|")([\w/-]+)('|")(.*)`),
+	string("node_id"): regexp.MustCompile(`[^\w]`),
+}
+
+const (
+	GRAPH_DOT     = "dot"
+	GRAPH_MERMAID = "mermaid"
+	GRAPH_JSON    = "json"
+)
+
+// dependency between two stores, counted by kind
+type StoreEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Kind  string `json:"kind"`
+	Count int    `json:"count"`
+}
+
+type StoreGraph struct {
+	Modules []string    `json:"modules"`
+	Edges   []StoreEdge `json:"edges"`
+}
+
+// Graph prints the dependencies between the modules of the store directory
+// in the given format
+func Graph(root string, format string) error {
+	var mg = newMigration(root, Config{})
+	mg.modules = mg.discoverModules()
+
+	graph, err := mg.buildGraph()
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case GRAPH_DOT:
+		fmt.Print(graph.dot())
+	case GRAPH_MERMAID:
+		fmt.Print(graph.mermaid())
+	case GRAPH_JSON:
+		content, err := json.MarshalIndent(graph, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(content))
+	default:
+		return fmt.Errorf("unsupported graph format '%s'", format)
+	}
+
+	return nil
+}
+
+// buildGraph collects the commit, dispatch, getter and state references
+// between the discovered modules of the store directory
+func (mg *migration) buildGraph() (StoreGraph, error) {
+	var graph = StoreGraph{Modules: []string{}, Edges: []StoreEdge{}}
+	var counts = map[StoreEdge]int{}
+
+	graphMg, rootFiles := mg.withInlineRoot()
+
+	for modulePath := range graphMg.modules {
+		graph.Modules = append(graph.Modules, modulePath)
+
+		for _, name := range []string{"actions", "mutations", "getters"} {
+			var files = [][]string{}
+
+			if lines, ok := rootFiles[name]; ok && modulePath == ROOT_MODULE {
+				files = append(files, lines)
+			}
+
+			for _, ext := range []string{".ts", ".js"} {
+				var path = filepath.Join(mg.root, modulePath, fmt.Sprint(name, ext))
+				if !fileExists(path) {
+					continue
+				}
+
+				content, err := os.ReadFile(path)
+				if err != nil {
+					return graph, err
+				}
+
+				files = append(files, strings.Split(string(content), "\n"))
+			}
+
+			for _, lines := range files {
+				for _, line := range lines {
+					for _, edge := range graphMg.lineDependencies(line, modulePath) {
+						counts[edge]++
+					}
+				}
+			}
+		}
+	}
+
+	for edge, count := range counts {
+		edge.Count = count
+		graph.Edges = append(graph.Edges, edge)
+	}
+
+	sort.Strings(graph.Modules)
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		var a, b = graph.Edges[i], graph.Edges[j]
+		return fmt.Sprint(a.From, " ", a.To, " ", a.Kind) < fmt.Sprint(b.From, " ", b.To, " ", b.Kind)
+	})
+
+	return graph, nil
+}
+
+// withInlineRoot returns a migration whose modules include the members
+// declared inline in the root store as the root module, with the files of
+// these members. The migration is returned as is when the root store has no
+// inline members or the root module exists
+func (mg *migration) withInlineRoot() (*migration, map[string][]string) {
+	if _, ok := mg.modules[ROOT_MODULE]; ok {
+		return mg, nil
+	}
+
+	rootPath, lines, err := readRootStore(mg.root)
+	if err != nil || rootPath == "" {
+		return mg, nil
+	}
+
+	// the members are reported when the root store is split
+	var graphMg = newMigration(mg.root, mg.config)
+
+	files, members := graphMg.rootStoreMembers(rootPath, lines)
+	if len(files) == 0 {
+		return mg, nil
+	}
+
+	// the members of the root store are global
+	var module = storeModule{}
+	for _, name := range members {
+		if name != "state" {
+			module.addMembers(name, files[name])
+		}
+	}
+
+	graphMg.modules = maps.Clone(mg.modules)
+	graphMg.modules[ROOT_MODULE] = module
+
+	return graphMg, files
+}
+
+// lineDependencies returns the edges from the current module to the modules
+// referenced by the line
+func (mg *migration) lineDependencies(line string, currentPath string) []StoreEdge {
+	var edges = []StoreEdge{}
+	var add = func(modulePath string, kind string) {
+		if _, ok := mg.modules[modulePath]; ok && modulePath != currentPath {
+			edges = append(edges, StoreEdge{From: currentPath, To: modulePath, Kind: kind})
+		}
+	}
+
+	for _, match := range graphPattern["call"].FindAllStringSubmatch(line, -1) {
+		if namespace, _, ok := cutLast(match[3], "/"); ok {
+			add(mg.resolveNamespace(namespace), match[1])
+			continue
+		}
+
+		for _, owner := range mg.globalOwners(match[3], false, currentPath, strings.Contains(match[5], "root: true")) {
+			add(owner, match[1])
+		}
+	}
+
+	for _, match := range storePattern["root_state_call"].FindAllStringSubmatch(line, -1) {
+		// state of the root store, e.g. `rootState.version`
+		if _, ok := mg.modules[match[1]]; !ok {
+			add(ROOT_MODULE, "state")
+			continue
+		}
+
+		add(match[1], "state")
+	}
+
+	for _, match := range storePattern["root_getter_call"].FindAllStringSubmatch(line, -1) {
+		add(mg.resolveNamespace(strings.TrimSuffix(match[2], "/")), "getter")
+	}
+
+	for _, match := range storePattern["global_getter"].FindAllStringSubmatch(line, -1) {
+		var name = match[3]
+		if name == "" {
+			name = match[5]
+		}
+
+		for _, owner := range mg.globalOwners(name, true, currentPath, match[1] == "rootGetters") {
+			add(owner, "getter")
+		}
+	}
+
+	return edges
+}
+
+func (g StoreGraph) dot() string {
+	var lines = []string{"digraph stores {"}
+
+	for _, module := range g.Modules {
+		lines = append(lines, fmt.Sprintf("  %q;", module))
+	}
+
+	for _, edge := range g.Edges {
+		lines = append(lines, fmt.Sprintf("  %q -> %q [label=%q];", edge.From, edge.To, edgeLabel(edge)))
+	}
+
+	return fmt.Sprintln(strings.Join(append(lines, "}"), "\n"))
+}
+
+func (g StoreGraph) mermaid() string {
+	var lines = []string{"graph LR"}
+
+	for _, module := range g.Modules {
+		lines = append(lines, fmt.Sprintf("  %s[\"%s\"]", mermaidId(module), module))
+	}
+
+	for _, edge := range g.Edges {
+		lines = append(lines, fmt.Sprintf("  %s -->|%s| %s", mermaidId(edge.From), edgeLabel(edge), mermaidId(edge.To)))
+	}
+
+	return fmt.Sprintln(strings.Join(lines, "\n"))
+}
+
+func edgeLabel(edge StoreEdge) string {
+	if edge.Count > 1 {
+		return fmt.Sprintf("%s x%d", edge.Kind, edge.Count)
+	}
+
+	return edge.Kind
+}
+
+// mermaidId returns a node id without the characters not allowed by mermaid
+func mermaidId(modulePath string) string {
+	return graphPattern["node_id"].ReplaceAllString(modulePath, "_")
+}
@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// cyclicStore writes a store where cart and user depend on each other and
+// orders depends on cart
+func cyclicStore(t *testing.T) *migration {
+	var root = t.TempDir()
+
+	writeModule(t, root, "cart", map[string]string{
+		"state.js":   cartModule["state.js"],
+		"getters.js": cartModule["getters.js"],
+		"actions.js": "export default {\n  checkout({ dispatch, rootState }) {\n    dispatch('user/load', null, { root: true });\n    dispatch('user/save', rootState.user.name, { root: true });\n  },\n};\n",
+	})
+	writeModule(t, root, "user", map[string]string{
+		"state.js":   "export default () => ({\n  name: '',\n});\n",
+		"actions.js": "export default {\n  load({ rootGetters }) {\n    return rootGetters['cart/count'];\n  },\n  save() {},\n};\n",
+	})
+	writeModule(t, root, "orders", map[string]string{
+		"actions.js": "export default {\n  place({ commit }) {\n    commit('cart/clear', null, { root: true });\n  },\n};\n",
+	})
+
+	var mg = newMigration(root, Config{})
+	mg.modules = mg.discoverModules()
+
+	return mg
+}
+
+func TestBuildGraph(t *testing.T) {
+	graph, err := cyclicStore(t).buildGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(graph.Modules, []string{"cart", "orders", "user"}) {
+		t.Errorf("modules = %v", graph.Modules)
+	}
+
+	var expected = []StoreEdge{
+		{From: "cart", To: "user", Kind: "dispatch", Count: 2},
+		{From: "cart", To: "user", Kind: "state", Count: 1},
+		{From: "orders", To: "cart", Kind: "commit", Count: 1},
+		{From: "user", To: "cart", Kind: "getter", Count: 1},
+	}
+
+	if !slices.Equal(graph.Edges, expected) {
+		t.Errorf("edges = %+v", graph.Edges)
+	}
+}
+
+func TestGraphFormats(t *testing.T) {
+	var graph = StoreGraph{
+		Modules: []string{"cart", "cart/items"},
+		Edges:   []StoreEdge{{From: "cart", To: "cart/items", Kind: "getter", Count: 2}},
+	}
+
+	if dot := graph.dot(); dot != "digraph stores {\n  \"cart\";\n  \"cart/items\";\n  \"cart\" -> \"cart/items\" [label=\"getter x2\"];\n}\n" {
+		t.Errorf("dot =\n%s", dot)
+	}
+
+	if mermaid := graph.mermaid(); mermaid != "graph LR\n  cart[\"cart\"]\n  cart_items[\"cart/items\"]\n  cart -->|getter x2| cart_items\n" {
+		t.Errorf("mermaid =\n%s", mermaid)
+	}
+}
+
+func TestBuildGraphInlineRootStore(t *testing.T) {
+	var root = t.TempDir()
+	writeModule(t, root, ".", map[string]string{"index.js": strings.Join([]string{
+		"import Vuex from 'vuex';",
+		"import cart from './cart';",
+		"export default new Vuex.Store({",
+		"  state: { version: 1 },",
+		"  getters: { v: (state) => state.version },",
+		"  mutations: { SET_V(state, version) { state.version = version; } },",
+		"  modules: { cart },",
+		"});",
+	}, "\n")})
+	writeModule(t, root, "cart", map[string]string{
+		"getters.js": "export default {\n  label: (state, getters, rootState, rootGetters) => `${rootState.version} ${rootGetters.v}`,\n};\n",
+		"actions.js": "export default {\n  upgrade({ commit }) {\n    commit('SET_V', 2, { root: true });\n  },\n};\n",
+	})
+
+	var mg = newMigration(root, Config{})
+	mg.modules = mg.discoverModules()
+
+	graph, err := mg.buildGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(graph.Modules, []string{"cart", ROOT_MODULE}) {
+		t.Errorf("modules = %v", graph.Modules)
+	}
+
+	var expected = []StoreEdge{
+		{From: "cart", To: ROOT_MODULE, Kind: "commit", Count: 1},
+		{From: "cart", To: ROOT_MODULE, Kind: "getter", Count: 1},
+		{From: "cart", To: ROOT_MODULE, Kind: "state", Count: 1},
+	}
+
+	if !slices.Equal(graph.Edges, expected) {
+		t.Errorf("edges = %+v", graph.Edges)
+	}
+
+	// the graph does not change the modules of the migration
+	if _, ok := mg.modules[ROOT_MODULE]; ok || len(mg.diagnostics) > 0 {
+		t.Errorf("migration changed by the graph: %v %v", mg.modules, mg.diagnostics)
+	}
+}
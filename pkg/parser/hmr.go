@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var hmrPattern = map[string]*regexp.Regexp{
+	string("hot_block"):  regexp.MustCompile(`^\s*if\s*\(\s*module\.hot\s*\)\s*\{$`),
+	string("hot_accept"): regexp.MustCompile(`^\s*module\.hot\.accept\(`),
+	string("hot_update"): regexp.MustCompile(`\bhotUpdate\(|\bmodule\.hot\.accept\(`),
+}
+
+// hmrLines returns the code accepting the hot updates of a store with vite
+func hmrLines(storeFn string) []string {
+	return []string{
+		"",
+		"if (import.meta.hot) {",
+		fmt.Sprintf("  import.meta.hot.accept(acceptHMRUpdate(%s, import.meta.hot));", storeFn),
+		"}",
+	}
+}
+
+// removeHotUpdate removes the webpack code updating the vuex modules, pinia
+// stores accept their own updates
+func (mg *migration) removeHotUpdate() error {
+	return filepath.WalkDir(mg.root, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && isOriginalsDir(entry) {
+			return filepath.SkipDir
+		}
+
+		if err != nil || entry.IsDir() || !(strings.HasSuffix(path, ".ts") || strings.HasSuffix(path, ".js")) {
+			return err
+		}
+
+		// the vuex store keeps accepting the updates of the modules not migrated
+		if !mg.isMigrated(mg.sourceModulePath(path)) {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil || hmrPattern["hot_update"].FindStringSubmatch(string(content)) == nil {
+			return err
+		}
+
+		var lines = strings.Split(string(content), "\n")
+		var result = []string{}
+
+		for index := 0; index < len(lines); index++ {
+			var line = lines[index]
+
+			if hmrPattern["hot_block"].FindStringSubmatch(line) == nil && hmrPattern["hot_accept"].FindStringSubmatch(line) == nil {
+				result = append(result, line)
+				continue
+			}
+
+			var start = index
+			var block = []string{line}
+
+			for balance := bracketBalance(line); balance > 0 && index+1 < len(lines); {
+				index++
+				balance += bracketBalance(lines[index])
+				block = append(block, lines[index])
+			}
+
+			if hmrPattern["hot_update"].FindStringSubmatch(strings.Join(block, "\n")) == nil {
+				result = append(result, block...)
+				continue
+			}
+
+			mg.addDiagnostic(path, start+1, "vuex hot update code removed, stores accept their own updates")
+		}
+
+		return os.WriteFile(path, []byte(strings.Join(result, "\n")), 0644)
+	})
+}
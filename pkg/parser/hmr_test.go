@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateAcceptsHotUpdates(t *testing.T) {
+	var root = t.TempDir()
+	var index = []string{
+		"import Vuex from 'vuex';",
+		"import cart from './cart';",
+		"",
+		"const store = new Vuex.Store({",
+		"  modules: { cart },",
+		"});",
+		"",
+		"if (module.hot) {",
+		"  module.hot.accept(['./cart'], () => {",
+		"    store.hotUpdate({ modules: { cart: require('./cart').default } });",
+		"  });",
+		"}",
+		"",
+		"export default store;",
+		"",
+	}
+
+	writeModule(t, root, ".", map[string]string{"index.js": strings.Join(index, "\n")})
+	writeModule(t, root, "cart", cartModule)
+
+	result, err := Migrate(context.Background(), root, Config{NoCache: true, HMR: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, "cart", "index.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertContains(t, "cart/index.js", string(content),
+		"import { acceptHMRUpdate, defineStore } from 'pinia';",
+		"if (import.meta.hot) {\n  import.meta.hot.accept(acceptHMRUpdate(useCartStore, import.meta.hot));\n}",
+	)
+
+	content, err = os.ReadFile(filepath.Join(root, "index.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(content), "hotUpdate") || strings.Contains(string(content), "module.hot") {
+		t.Errorf("index.js keeps the vuex hot update:\n%s", content)
+	}
+
+	var removed = false
+	for _, diagnostic := range result.Diagnostics {
+		removed = removed || strings.HasPrefix(diagnostic.Message, "vuex hot update code removed")
+	}
+
+	if !removed {
+		t.Errorf("hot update removal not reported: %+v", result.Diagnostics)
+	}
+}
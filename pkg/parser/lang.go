@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// the language of each module is kept
+	LANG_AUTO = "auto"
+	LANG_JS   = "js"
+	LANG_TS   = "ts"
+)
+
+// moduleLang returns the language of the files created for a module, in
+// auto mode a module with any typescript file is a typescript module
+func (mg *migration) moduleLang(filesMap map[string]*os.File) string {
+	if mg.config.Lang != LANG_AUTO {
+		return mg.config.Lang
+	}
+
+	for _, file := range filesMap {
+		if strings.HasSuffix(file.Name(), ".ts") {
+			return LANG_TS
+		}
+	}
+
+	return LANG_JS
+}
+
+// checkJavascriptSources returns an error when the store has typescript
+// files, they are not converted to javascript
+func (mg *migration) checkJavascriptSources() error {
+	return filepath.WalkDir(mg.root, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && isOriginalsDir(entry) {
+			return filepath.SkipDir
+		}
+
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		if strings.HasSuffix(path, ".ts") && !strings.HasSuffix(path, ".d.ts") {
+			return fmt.Errorf("%s: typescript sources are not converted to javascript, migrate them with the ts or auto language", path)
+		}
+
+		return nil
+	})
+}
+
+// convertToTypescript renames the javascript actions, getters and mutations
+// of a module to be parsed as typescript, the state is typed from its
+// initial values by typeStateFile
+func convertToTypescript(filesMap map[string]*os.File) error {
+	for _, name := range []string{"actions", "getters", "mutations"} {
+		file, ok := filesMap[name]
+		if !ok || !strings.HasSuffix(file.Name(), ".js") {
+			continue
+		}
+
+		var path = fmt.Sprint(strings.TrimSuffix(file.Name(), ".js"), ".ts")
+
+		file.Close()
+
+		if err := os.Rename(file.Name(), path); err != nil {
+			return err
+		}
+
+		converted, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+
+		filesMap[name] = converted
+	}
+
+	return nil
+}
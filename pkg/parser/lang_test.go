@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateConvertsJavascriptToTypescript(t *testing.T) {
+	var root = t.TempDir()
+	writeModule(t, root, "cart", cartModule)
+
+	if _, err := Migrate(context.Background(), root, Config{Lang: LANG_TS, NoCache: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"index.ts", "state.ts", "getters.ts", "actions.ts"} {
+		if !fileExists(filepath.Join(root, "cart", name)) {
+			t.Errorf("cart/%s was not created", name)
+		}
+	}
+
+	for _, name := range []string{"state.js", "getters.js", "actions.js", "mutations.js", "mutations.ts"} {
+		if fileExists(filepath.Join(root, "cart", name)) {
+			t.Errorf("cart/%s was not removed", name)
+		}
+	}
+}
+
+func TestMigrateRejectsTypescriptToJavascript(t *testing.T) {
+	var root = t.TempDir()
+	writeModule(t, root, "cart", map[string]string{
+		"state.ts":   "export default () => ({\n  items: [] as string[],\n});\n",
+		"actions.ts": "export default {\n  load() {},\n};\n",
+	})
+
+	if _, err := Migrate(context.Background(), root, Config{Lang: LANG_JS, NoCache: true}); err == nil {
+		t.Fatalf("typescript module migrated to javascript")
+	}
+
+	if !fileExists(filepath.Join(root, "cart", "actions.ts")) || fileExists(filepath.Join(root, "cart", "index.js")) {
+		t.Errorf("module was changed before rejecting the language")
+	}
+}
+
+func TestMigrateDocumentsJavascriptState(t *testing.T) {
+	var migrated = migratedModule(t, map[string]string{"state.js": "export default () => ({\n  items: [],\n  total: 0,\n});\n"}, Config{Lang: LANG_JS})
+
+	var expected = "/**\n * @typedef {Object} CartState\n * @property {unknown[]} items TODO set the type\n * @property {number} total\n */\n\n/** @returns {CartState} */\nexport default () => ({\n  items: [],\n  total: 0,\n});\n"
+
+	if migrated["state.js"] != expected {
+		t.Errorf("state.js migrated as\n%s", migrated["state.js"])
+	}
+
+	if _, ok := migrated["index.js"]; !ok {
+		t.Error("index.js was not created")
+	}
+}
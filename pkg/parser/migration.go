@@ -0,0 +1,139 @@
+package parser
+
+import (
+	"context"
+	"log"
+	"runtime"
+	"sync"
+)
+
+// Config of a migration, the zero value migrates every module keeping the
+// language of each one
+type Config struct {
+	// framework of the store, e.g. nuxt2
+	Framework string
+	// language of the created files, auto when empty
+	Lang string
+	// appends the hot module replacement code to the stores
+	HMR bool
+	// writes the state of javascript modules as typescript with an interface
+	// inferred from the initial values
+	TypedState bool
+	// migrates again the modules migrated by a previous run, from the vuex
+	// files kept in the originals directory
+	Force bool
+	// translates every module instead of reusing the previous run
+	NoCache bool
+	// paths of the modules to migrate, every module is migrated when empty
+	Modules []string
+	// paths of the modules changed since the previous run, the others are
+	// restored from the cache when the store did not change. Every module is
+	// checked when empty
+	Changed []string
+	// number of modules translated at the same time, the number of CPUs
+	// when zero
+	Jobs    int
+	Verbose bool
+	Debug   bool
+	// output of the progress and the warnings, nothing is printed when nil
+	Logger *log.Logger
+}
+
+// outcome of the migration of a module
+type ModuleResult struct {
+	// path of the module relative to the store directory, e.g. `cart/items`
+	Path   string
+	Status string
+	// error translating the module when its status is failed
+	Err         error
+	Diagnostics []Diagnostic
+}
+
+type Result struct {
+	Modules []ModuleResult
+	// diagnostics not related to a module, e.g. the root store and plugins
+	Diagnostics []Diagnostic
+}
+
+// state of the migration of a store directory
+type migration struct {
+	config Config
+	// store directory, module paths are relative to it
+	root string
+	// modules keyed by the module path, e.g. `cart/items`
+	modules map[string]storeModule
+	// persist option of the stores keyed by the module path
+	persist map[string]string
+	// modules depending on each other
+	cycles [][]string
+	// the members of the root store were moved to the root module
+	splitRoot bool
+	// modules migrated by a previous run, found before the passes rewrite
+	// the store
+	piniaModules map[string]bool
+	// diagnostics not printed yet, modules are translated concurrently
+	diagnostics      []Diagnostic
+	diagnosticsMutex sync.Mutex
+	result           *Result
+}
+
+func newMigration(root string, config Config) *migration {
+	if config.Lang == "" {
+		config.Lang = LANG_AUTO
+	}
+
+	if config.Jobs < 1 {
+		config.Jobs = runtime.NumCPU()
+	}
+
+	return &migration{
+		config:       config,
+		root:         root,
+		modules:      map[string]storeModule{},
+		persist:      map[string]string{},
+		cycles:       [][]string{},
+		piniaModules: map[string]bool{},
+		diagnostics:  []Diagnostic{},
+		result:       &Result{Modules: []ModuleResult{}, Diagnostics: []Diagnostic{}},
+	}
+}
+
+// Migrate translates the vuex store directory in place, returning the
+// outcome of each module
+func Migrate(ctx context.Context, root string, config Config) (*Result, error) {
+	var mg = newMigration(root, config)
+
+	if err := mg.run(ctx); err != nil {
+		return mg.result, err
+	}
+
+	return mg.result, nil
+}
+
+// printf writes a message to the logger of the migration
+func (mg *migration) printf(format string, a ...any) {
+	if mg.config.Logger != nil {
+		mg.config.Logger.Printf(format, a...)
+	}
+}
+
+// verbosef writes a message to the logger in verbose mode
+func (mg *migration) verbosef(format string, a ...any) {
+	if mg.config.Verbose {
+		mg.printf(format, a...)
+	}
+}
+
+// printErr writes the error stopping the migration in verbose mode
+func (mg *migration) printErr(err error) {
+	if err != nil {
+		mg.verbosef("Err: %s", err)
+	}
+}
+
+// printMemUsage writes the memory usage in debug mode
+func (mg *migration) printMemUsage() {
+	if mg.config.Debug {
+		mg.printf("%s", memUsage())
+	}
+}
@@ -0,0 +1,186 @@
+package parser
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+var modulePattern = map[string]*regexp.Regexp{
+	string("function_name"): regexp.MustCompile(`^\s{2}(async\s)?(\w+)(\(|:\s)`),
+	string("module_file"):   regexp.MustCompile(`^(actions|mutations|getters|state|index)\.(ts|js)$`),
+	string("namespaced"):    regexp.MustCompile(`\bnamespaced:\s*(true|false)`),
+}
+
+type storeModule struct {
+	// actions and mutations defined by the module
+	actions []string
+	// mutations only, to keep them apart once merged into the actions
+	mutations []string
+	getters   []string
+	// modules without namespace share the actions, mutations and getters
+	namespaced bool
+	// namespace used to reference the module, only namespaced ancestors
+	// are part of it
+	namespace string
+}
+
+// addMembers collects the names of the getters, mutations or actions
+// defined by the lines of a module file
+func (module *storeModule) addMembers(kind string, lines []string) {
+	for _, line := range lines {
+		if fn := modulePattern["function_name"].FindStringSubmatch(line); fn != nil {
+			if kind == "getters" {
+				module.getters = append(module.getters, fn[2])
+			} else {
+				module.actions = append(module.actions, fn[2])
+			}
+
+			if kind == "mutations" {
+				module.mutations = append(module.mutations, fn[2])
+			}
+		}
+	}
+}
+
+// discoverModules walks the store directory collecting the names of the
+// actions, mutations and getters of every module and its namespace
+func (mg *migration) discoverModules() map[string]storeModule {
+	var modules = map[string]storeModule{}
+
+	filepath.WalkDir(mg.root, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && isOriginalsDir(entry) {
+			return filepath.SkipDir
+		}
+
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+
+		match := modulePattern["module_file"].FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil
+		}
+
+		dir, err := filepath.Rel(mg.root, filepath.Dir(path))
+		if err != nil || dir == "." {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			// TODO skips files with errors for now
+			return nil
+		}
+
+		var modulePath = filepath.ToSlash(dir)
+		module, ok := modules[modulePath]
+		if !ok {
+			// modules without an object definition are considered namespaced,
+			// the members of the root store are global
+			module = storeModule{namespaced: !(mg.splitRoot && modulePath == ROOT_MODULE)}
+		}
+
+		if match[1] == "index" {
+			namespaced := modulePattern["namespaced"].FindStringSubmatch(string(content))
+			module.namespaced = namespaced != nil && namespaced[1] == "true"
+		} else if match[1] != "state" {
+			module.addMembers(match[1], strings.Split(string(content), "\n"))
+		}
+
+		modules[modulePath] = module
+
+		return nil
+	})
+
+	for modulePath, module := range modules {
+		var namespace = []string{}
+		var segments = strings.Split(modulePath, "/")
+
+		for index := range segments {
+			if ancestor, ok := modules[strings.Join(segments[:index+1], "/")]; !ok || ancestor.namespaced {
+				namespace = append(namespace, segments[index])
+			}
+		}
+
+		module.namespace = strings.Join(namespace, "/")
+		modules[modulePath] = module
+	}
+
+	return modules
+}
+
+// modulePathOf returns the path of the module of a store file
+func (mg *migration) modulePathOf(filename string) string {
+	dir, err := filepath.Rel(mg.root, filepath.Dir(filename))
+	if err != nil {
+		return ""
+	}
+
+	return filepath.ToSlash(dir)
+}
+
+// resolveNamespace returns the path of the module registered with the given
+// namespace, which differs when an ancestor is not namespaced
+func (mg *migration) resolveNamespace(namespace string) string {
+	for modulePath, module := range mg.modules {
+		if module.namespace == namespace && module.namespaced {
+			return modulePath
+		}
+	}
+
+	return namespace
+}
+
+// modulesDefining returns the paths of the modules with an action or a
+// mutation with the given name
+func (mg *migration) modulesDefining(name string) []string {
+	var paths = []string{}
+
+	for modulePath, module := range mg.modules {
+		if slices.Contains(module.actions, name) {
+			paths = append(paths, modulePath)
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+// globalOwners returns the modules without namespace defining the action,
+// mutation or getter. It is empty when the name belongs to the current
+// module, or when the current module is namespaced and the name is not
+// referenced from the root
+func (mg *migration) globalOwners(name string, getter bool, currentPath string, root bool) []string {
+	var current, ok = mg.modules[currentPath]
+	var names = current.actions
+	if getter {
+		names = current.getters
+	}
+
+	if !root && (!ok || current.namespaced || slices.Contains(names, name)) {
+		return []string{}
+	}
+
+	var paths = []string{}
+
+	for modulePath, module := range mg.modules {
+		names = module.actions
+		if getter {
+			names = module.getters
+		}
+
+		if !module.namespaced && slices.Contains(names, name) {
+			paths = append(paths, modulePath)
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
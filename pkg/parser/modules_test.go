@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"slices"
+	"testing"
+)
+
+// globalStore writes a store with namespaced and non-namespaced modules
+func globalStore(t *testing.T) *migration {
+	var root = t.TempDir()
+
+	writeModule(t, root, "cart", map[string]string{
+		"index.js":   "export default {\n  namespaced: true,\n};\n",
+		"actions.js": "export default {\n  checkout() {},\n};\n",
+	})
+	writeModule(t, root, "cart/items", map[string]string{
+		"getters.js": "export default {\n  count(state) {},\n};\n",
+	})
+	writeModule(t, root, "settings", map[string]string{
+		"index.js":     "export default {\n  namespaced: false,\n};\n",
+		"getters.js":   "export default {\n  theme(state) {},\n};\n",
+		"mutations.js": "export default {\n  setTheme(state, theme) {},\n};\n",
+	})
+	writeModule(t, root, "settings/panel", map[string]string{
+		"index.js":   "export default {\n  namespaced: true,\n};\n",
+		"getters.js": "export default {\n  open(state) {},\n};\n",
+	})
+	writeModule(t, root, "layout", map[string]string{
+		"index.js":   "export default {\n  namespaced: false,\n};\n",
+		"getters.js": "export default {\n  dark(state, getters) {},\n};\n",
+	})
+
+	var mg = newMigration(root, Config{})
+	mg.modules = mg.discoverModules()
+
+	return mg
+}
+
+func TestDiscoverModules(t *testing.T) {
+	var mg = globalStore(t)
+
+	var tests = map[string]storeModule{
+		"cart":           {actions: []string{"checkout"}, namespaced: true, namespace: "cart"},
+		"cart/items":     {getters: []string{"count"}, namespaced: true, namespace: "cart/items"},
+		"settings":       {actions: []string{"setTheme"}, mutations: []string{"setTheme"}, getters: []string{"theme"}, namespace: ""},
+		"settings/panel": {getters: []string{"open"}, namespaced: true, namespace: "panel"},
+	}
+
+	for modulePath, expected := range tests {
+		module := mg.modules[modulePath]
+
+		if module.namespaced != expected.namespaced || module.namespace != expected.namespace || !slices.Equal(module.actions, expected.actions) || !slices.Equal(module.mutations, expected.mutations) || !slices.Equal(module.getters, expected.getters) {
+			t.Errorf("%s discovered as %+v", modulePath, module)
+		}
+	}
+
+	if modulePath := mg.resolveNamespace("panel"); modulePath != "settings/panel" {
+		t.Errorf("panel namespace resolved to %s", modulePath)
+	}
+}
+
+func TestGlobalOwners(t *testing.T) {
+	var mg = globalStore(t)
+
+	var tests = []struct {
+		name        string
+		getter      bool
+		currentPath string
+		root        bool
+		expected    []string
+	}{
+		{"theme", true, "layout", false, []string{"settings"}},
+		{"setTheme", false, "layout", false, []string{"settings"}},
+		// namespaced modules reference the global names from the root
+		{"theme", true, "cart", false, []string{}},
+		{"theme", true, "cart", true, []string{"settings"}},
+		// the name belongs to the current module
+		{"theme", true, "settings", false, []string{}},
+	}
+
+	for _, test := range tests {
+		if owners := mg.globalOwners(test.name, test.getter, test.currentPath, test.root); !slices.Equal(owners, test.expected) {
+			t.Errorf("globalOwners(%s, %s) = %v", test.name, test.currentPath, owners)
+		}
+	}
+}
+
+func TestReplaceGlobalGetters(t *testing.T) {
+	var stores = crossStores{mg: globalStore(t)}
+
+	line, defLines := stores.replaceGlobalGetters("return getters.theme === 'dark' && getters.dark;", "layout")
+	if line != "return settingsStore.theme === 'dark' && getters.dark;" || !slices.Equal(defLines, []string{"    const settingsStore = useSettingsStore()"}) {
+		t.Errorf("replaceGlobalGetters = %q, %q", line, defLines)
+	}
+}
@@ -0,0 +1,293 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var fnPattern = map[string]*regexp.Regexp{
+	string("property_arrow"):    regexp.MustCompile(`^(\s{2})(\w+):\s(async\s)?(\(([^()]*)\)|(\w+))(\:\s[^=]+?)?\s=>\s?(.*)$`),
+	string("property_function"): regexp.MustCompile(`^(\s{2})(\w+):\s(async\s)?function\s?\((.*)\)(\:\s.+?)?\s\{$`),
+	string("const_arrow"):       regexp.MustCompile(`^(export\s)?const\s(\w+)(\:\s[^=]+)?\s=\s(async\s)?(\(([^()]*)\)|(\w+))(\:\s[^=]+?)?\s=>\s?(.*)$`),
+	string("const_function"):    regexp.MustCompile(`^(export\s)?(async\s)?function\s(\w+)\((.*)\)(\:\s.+?)?\s\{$`),
+	string("object_start"):      regexp.MustCompile(`^((export\sdefault)|((export\s)?const\s\w+(\:\s.+)?\s=))\s\{$`),
+	string("object_inline"):     regexp.MustCompile(`^((export\sdefault)|((export\s)?const\s\w+(\:\s.+)?\s=))\s\{(.*)\};?$`),
+	string("object_shorthand"):  regexp.MustCompile(`^\s{2}(\w+),?$`),
+	string("block_end"):         regexp.MustCompile(`^\}\)?;?$`),
+}
+
+// function declared outside of the exported object
+type constFunction struct {
+	start  int
+	end    int
+	method []string
+}
+
+// normalizeFunctions reads the file converting arrow functions, function
+// expressions and functions declared as constants into method shorthand,
+// the format expected by the parsers and required by pinia to access `this`
+func (mg *migration) normalizeFunctions(file *os.File) io.Reader {
+	var lines = []string{}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	lines = inlineConstFunctions(lines)
+
+	var normalized = []string{}
+
+	for index := 0; index < len(lines); index++ {
+		line := lines[index]
+
+		if match := fnPattern["property_function"].FindStringSubmatch(line); match != nil {
+			line = fmt.Sprintf("%s%s%s(%s)%s {", match[1], match[3], match[2], match[4], match[5])
+		}
+
+		if match := fnPattern["property_arrow"].FindStringSubmatch(line); match != nil {
+			var params = match[5]
+			if !strings.HasPrefix(match[4], "(") {
+				params = match[6]
+			}
+
+			var header = fmt.Sprintf("%s%s%s(%s)%s", match[1], match[3], match[2], params, match[7])
+			var body []string
+
+			body, index = arrowBody(lines, index, match[8], ",")
+			if body == nil {
+				normalized = append(normalized, fmt.Sprintf("%s %s", header, match[8]))
+				continue
+			}
+
+			normalized = append(normalized, fmt.Sprintf("%s {", header))
+			normalized = append(normalized, indentLines(body, match[1])...)
+			normalized = append(normalized, fmt.Sprintf("%s},", match[1]))
+
+			continue
+		}
+
+		normalized = append(normalized, line)
+	}
+
+	normalized = mg.stripVuexTypes(normalized, file.Name())
+
+	return strings.NewReader(strings.Join(normalized, "\n"))
+}
+
+// arrowBody returns the lines of a concise body arrow function as a block
+// body, the returned index points to the last line of the expression. The
+// body is nil when the arrow function already has a block body
+func arrowBody(lines []string, index int, expr string, separator string) ([]string, int) {
+	if strings.HasPrefix(expr, "{") {
+		return nil, index
+	}
+
+	// expression starts in the next line
+	if expr == "" && index+1 < len(lines) {
+		index++
+		expr = strings.TrimSpace(lines[index])
+	}
+
+	var exprLines = []string{expr}
+	var balance = bracketBalance(expr)
+
+	for balance > 0 && index+1 < len(lines) {
+		index++
+		exprLines = append(exprLines, lines[index])
+		balance += bracketBalance(lines[index])
+	}
+
+	var last = len(exprLines) - 1
+
+	exprLines[last] = strings.TrimSuffix(strings.TrimRight(exprLines[last], " "), separator)
+	exprLines[0] = fmt.Sprintf("  return %s", exprLines[0])
+	exprLines[last] = fmt.Sprintf("%s;", strings.TrimSuffix(exprLines[last], ";"))
+
+	return exprLines, index
+}
+
+// inlineConstFunctions moves functions declared as constants into the
+// object that references them by shorthand property, e.g. `{ foo, bar }`
+func inlineConstFunctions(lines []string) []string {
+	var functions = map[string]constFunction{}
+
+	for index := 0; index < len(lines); index++ {
+		var name string
+		var header string
+		var rest string
+
+		if match := fnPattern["const_arrow"].FindStringSubmatch(lines[index]); match != nil {
+			var params = match[6]
+			if !strings.HasPrefix(match[5], "(") {
+				params = match[7]
+			}
+
+			name = match[2]
+			header = fmt.Sprintf("  %s%s(%s)%s", match[4], name, params, match[8])
+			rest = match[9]
+		} else if match := fnPattern["const_function"].FindStringSubmatch(lines[index]); match != nil {
+			name = match[3]
+			header = fmt.Sprintf("  %s%s(%s)%s", match[2], name, match[4], match[5])
+			rest = "{"
+		} else {
+			continue
+		}
+
+		var start = index
+		var method = []string{}
+
+		body, end := arrowBody(lines, index, rest, ";")
+		if body != nil {
+			method = append(method, fmt.Sprintf("%s {", header))
+			method = append(method, indentLines(body, "  ")...)
+		} else if strings.HasSuffix(strings.TrimSuffix(rest, ";"), "}") {
+			// single line block body
+			method = append(method, fmt.Sprintf("%s %s", header, strings.TrimSuffix(strings.TrimSuffix(rest, ";"), "}")))
+		} else {
+			method = append(method, fmt.Sprintf("%s {", header))
+
+			for end = index + 1; end < len(lines); end++ {
+				if fnPattern["block_end"].FindStringSubmatch(lines[end]) != nil {
+					break
+				}
+
+				method = append(method, indentLines([]string{lines[end]}, "  ")...)
+			}
+		}
+
+		method = append(method, "  },")
+		functions[name] = constFunction{start: start, end: end, method: method}
+		index = end
+	}
+
+	if len(functions) == 0 {
+		return lines
+	}
+
+	var inlined = referencedFunctions(lines, functions)
+	var result = []string{}
+	var insideObject = false
+
+	for index, line := range lines {
+		// remove declarations of inlined functions and the blank line after them
+		if slices.ContainsFunc(inlined, func(name string) bool {
+			return index >= functions[name].start && index <= functions[name].end+1 && (index <= functions[name].end || line == "")
+		}) {
+			continue
+		}
+
+		if match := fnPattern["object_inline"].FindStringSubmatch(line); match != nil {
+			var props = splitProps(match[6])
+
+			if slices.ContainsFunc(props, func(prop string) bool { return slices.Contains(inlined, prop) }) {
+				result = append(result, fmt.Sprintf("%s {", match[1]))
+
+				for _, prop := range props {
+					if slices.Contains(inlined, prop) {
+						result = append(result, functions[prop].method...)
+					} else {
+						result = append(result, fmt.Sprintf("  %s,", prop))
+					}
+				}
+
+				result = append(result, "};")
+				continue
+			}
+		}
+
+		if fnPattern["object_start"].FindStringSubmatch(line) != nil {
+			insideObject = true
+		} else if fnPattern["block_end"].FindStringSubmatch(line) != nil {
+			insideObject = false
+		}
+
+		if match := fnPattern["object_shorthand"].FindStringSubmatch(line); match != nil && insideObject {
+			if slices.Contains(inlined, match[1]) {
+				result = append(result, functions[match[1]].method...)
+				continue
+			}
+		}
+
+		result = append(result, line)
+	}
+
+	return result
+}
+
+// referencedFunctions returns the functions used as shorthand properties of
+// an object, only those are moved into the object
+func referencedFunctions(lines []string, functions map[string]constFunction) []string {
+	var referenced = []string{}
+	var insideObject = false
+
+	for _, line := range lines {
+		var props = []string{}
+
+		if match := fnPattern["object_inline"].FindStringSubmatch(line); match != nil {
+			props = splitProps(match[6])
+		} else if fnPattern["object_start"].FindStringSubmatch(line) != nil {
+			insideObject = true
+		} else if fnPattern["block_end"].FindStringSubmatch(line) != nil {
+			insideObject = false
+		} else if match := fnPattern["object_shorthand"].FindStringSubmatch(line); match != nil && insideObject {
+			props = append(props, match[1])
+		}
+
+		for _, prop := range props {
+			if _, ok := functions[prop]; ok && !slices.Contains(referenced, prop) {
+				referenced = append(referenced, prop)
+			}
+		}
+	}
+
+	return referenced
+}
+
+func splitProps(props string) []string {
+	var result = []string{}
+
+	for _, prop := range strings.Split(props, ",") {
+		if prop = strings.TrimSpace(prop); prop != "" {
+			result = append(result, prop)
+		}
+	}
+
+	return result
+}
+
+func indentLines(lines []string, indent string) []string {
+	var indented = []string{}
+
+	for _, line := range lines {
+		if line == "" {
+			indented = append(indented, line)
+			continue
+		}
+
+		indented = append(indented, fmt.Sprint(indent, line))
+	}
+
+	return indented
+}
+
+// bracketBalance returns the number of opened brackets minus the closed ones
+func bracketBalance(line string) int {
+	var balance = 0
+
+	for _, char := range line {
+		switch char {
+		case '(', '[', '{':
+			balance++
+		case ')', ']', '}':
+			balance--
+		}
+	}
+
+	return balance
+}
@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestNormalizeFunctions(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "actions.js")
+	var source = `const helper = (state, id) => state.items[id];
+
+export async function load({ commit }, id) {
+  commit('set', id);
+}
+
+export default {
+  load,
+  add: function ({ commit }, item) {
+    commit('add', item);
+  },
+  remove: async ({ commit }, id) => {
+    commit('remove', id);
+  },
+  clear: ({ commit }) => commit('clear'),
+  find: (state) => helper(state, 1),
+};
+`
+	var expected = `const helper = (state, id) => state.items[id];
+
+export default {
+  async load({ commit }, id) {
+    commit('set', id);
+  },
+  add({ commit }, item) {
+    commit('add', item);
+  },
+  async remove({ commit }, id) {
+    commit('remove', id);
+  },
+  clear({ commit }) {
+    return commit('clear');
+  },
+  find(state) {
+    return helper(state, 1);
+  },
+};`
+
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(newMigration(t.TempDir(), Config{}).normalizeFunctions(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != expected {
+		t.Errorf("normalized as\n%s", content)
+	}
+}
+
+func TestSplitProps(t *testing.T) {
+	var props = splitProps(" add, remove: clear, find, ")
+
+	if !slices.Equal(props, []string{"add", "remove: clear", "find"}) {
+		t.Errorf("splitProps = %q", props)
+	}
+}
+
+func TestBracketBalance(t *testing.T) {
+	var tests = map[string]int{
+		"add({ commit }, item) {": 1,
+		"}),":                     -2,
+		"find: (id) => ({":        2,
+		"commit('add', [item]);":  0,
+	}
+
+	for line, expected := range tests {
+		if balance := bracketBalance(line); balance != expected {
+			t.Errorf("bracketBalance(%q) = %d, expected %d", line, balance, expected)
+		}
+	}
+}
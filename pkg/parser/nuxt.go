@@ -0,0 +1,355 @@
+package parser
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var nuxtPattern = map[string]*regexp.Regexp{
+	string("export"):           regexp.MustCompile(`^export\s+(const|let|function|async\s+function)\s+(\w+)\b(\:\s[^=]+)?\s*=?\s*(.*)$`),
+	string("declaration"):      regexp.MustCompile(`^(export\s+)?(const|let|var|function|async\s+function|class)\s+(\w+)\b`),
+	string("import"):           regexp.MustCompile(`^import\s+(.+)\s+from\s+('|").+('|");?$`),
+	string("import_names"):     regexp.MustCompile(`\w+`),
+	string("server_init"):      regexp.MustCompile(`^\s{2}(async\s+)?nuxtServerInit\((.*)\)\s*\{$`),
+	string("property_end"):     regexp.MustCompile(`^\s{2}\},?$`),
+	string("store_alias"):      regexp.MustCompile(`(('|")(~|@)/)store/`),
+	string("module_filename"):  regexp.MustCompile(`^(actions|mutations|getters|state)\.(ts|js)$`),
+	string("ignored_filename"): regexp.MustCompile(`(\.spec|\.test|\.d)\.(ts|js)$`),
+}
+
+const (
+	NUXT2_FRAMEWORK = "nuxt2"
+	// module created from the root file of the nuxt store
+	NUXT_ROOT_MODULE = "root"
+	// nuxt plugin created from the nuxtServerInit action
+	NUXT_SERVER_INIT = "nuxt-server-init"
+	// file with the declarations shared by the module properties
+	NUXT_HELPERS = "helpers"
+)
+
+var nuxtModuleProps = []string{"state", "getters", "mutations", "actions"}
+
+// splitNuxtModules converts the nuxt store files exporting the state,
+// getters, mutations and actions into module directories, the namespace of
+// each module is the path of its file
+func (mg *migration) splitNuxtModules() error {
+	var sources = []string{}
+
+	err := filepath.WalkDir(mg.root, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && isOriginalsDir(entry) {
+			return filepath.SkipDir
+		}
+
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		var name = entry.Name()
+		if !(strings.HasSuffix(name, ".ts") || strings.HasSuffix(name, ".js")) || nuxtPattern["ignored_filename"].FindStringSubmatch(name) != nil {
+			return nil
+		}
+
+		// the modules not migrated are kept as vuex modules
+		if !mg.isMigrated(mg.sourceModulePath(path)) {
+			return nil
+		}
+
+		sources = append(sources, path)
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, path := range sources {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		// imports of the old store directory
+		var lines = strings.Split(nuxtPattern["store_alias"].ReplaceAllString(string(content), "${1}stores/"), "\n")
+
+		if nuxtPattern["module_filename"].FindStringSubmatch(filepath.Base(path)) != nil || !isNuxtModule(lines) {
+			if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		var moduleDir = mg.nuxtModuleDir(path)
+		if moduleDir == filepath.Join(mg.root, NUXT_ROOT_MODULE) {
+			mg.addDiagnostic(path, 0, "root state is moved to the %s store", NUXT_ROOT_MODULE)
+			// the members of the root store are global
+			mg.splitRoot = true
+		}
+
+		if err := mg.splitNuxtModule(path, lines, moduleDir); err != nil {
+			return err
+		}
+
+		mg.verbosef("Split %s into %s", path, moduleDir)
+	}
+
+	return nil
+}
+
+// nuxtModuleDir returns the directory of the module defined by a nuxt store
+// file, the files of the root store go to the root module
+func (mg *migration) nuxtModuleDir(path string) string {
+	if nuxtPattern["module_filename"].FindStringSubmatch(filepath.Base(path)) != nil {
+		return filepath.Dir(path)
+	}
+
+	var moduleDir = strings.TrimSuffix(path, filepath.Ext(path))
+	if filepath.Base(moduleDir) == "index" {
+		moduleDir = filepath.Dir(moduleDir)
+	}
+
+	if moduleDir == mg.root {
+		return filepath.Join(mg.root, NUXT_ROOT_MODULE)
+	}
+
+	return moduleDir
+}
+
+// isNuxtModule checks if the file exports any of the module properties
+func isNuxtModule(lines []string) bool {
+	return slices.ContainsFunc(lines, func(line string) bool {
+		match := nuxtPattern["export"].FindStringSubmatch(line)
+		return match != nil && slices.Contains(nuxtModuleProps, match[2])
+	})
+}
+
+// splitNuxtModule writes each exported property of the module into its own
+// file, imports and declarations are copied where they are used
+func (mg *migration) splitNuxtModule(path string, lines []string, moduleDir string) error {
+	var ext = filepath.Ext(path)
+	var imports = []string{}
+	var declarations = [][]string{}
+	var props = map[string][]string{}
+
+	for index := 0; index < len(lines); index++ {
+		var line = lines[index]
+
+		if nuxtPattern["import"].FindStringSubmatch(line) != nil {
+			imports = append(imports, line)
+			continue
+		}
+
+		if nuxtPattern["declaration"].FindStringSubmatch(line) == nil {
+			if strings.TrimSpace(line) != "" {
+				mg.addDiagnostic(path, index+1, "top level statement is not moved to the store files")
+			}
+
+			continue
+		}
+
+		var block = []string{line}
+		for balance := bracketBalance(line); balance > 0 && index+1 < len(lines); {
+			index++
+			balance += bracketBalance(lines[index])
+			block = append(block, lines[index])
+		}
+
+		match := nuxtPattern["export"].FindStringSubmatch(line)
+		if match == nil || !slices.Contains(nuxtModuleProps, match[2]) {
+			if match != nil {
+				mg.addDiagnostic(path, index+1, "export %s is not supported by pinia", match[2])
+			}
+
+			declarations = append(declarations, block)
+			continue
+		}
+
+		props[match[2]] = block
+	}
+
+	if actions, ok := props["actions"]; ok {
+		actions, err := mg.extractServerInit(path, actions, imports)
+		if err != nil {
+			return err
+		}
+
+		props["actions"] = actions
+	}
+
+	for _, prop := range nuxtModuleProps {
+		block, ok := props[prop]
+		if !ok {
+			continue
+		}
+
+		var content = nuxtPropFile(prop, block)
+		var helpers = imports
+
+		if names := usedDeclarations(content, declarations); len(names) > 0 {
+			helpers = append(slices.Clone(imports), fmt.Sprintf("import { %s } from './%s';", strings.Join(names, ", "), NUXT_HELPERS))
+		}
+
+		content = append(usedImports(content, helpers), content...)
+
+		var filename = filepath.Join(moduleDir, fmt.Sprint(prop, ext))
+		if err := os.MkdirAll(moduleDir, 0755); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(filename, []byte(strings.Join(content, "\n")), 0644); err != nil {
+			return err
+		}
+	}
+
+	if len(declarations) > 0 {
+		if err := writeNuxtHelpers(filepath.Join(moduleDir, fmt.Sprint(NUXT_HELPERS, ext)), declarations, imports); err != nil {
+			return err
+		}
+	}
+
+	return os.Remove(path)
+}
+
+// nuxtPropFile returns the lines of the file defining a module property with
+// a default export
+func nuxtPropFile(prop string, block []string) []string {
+	var lines = slices.Clone(block)
+	match := nuxtPattern["export"].FindStringSubmatch(lines[0])
+
+	if prop == "state" {
+		if strings.Contains(match[1], "function") {
+			lines[0] = strings.Replace(lines[0], "export ", "export default ", 1)
+		} else if strings.HasPrefix(match[4], "{") {
+			// pinia state must be a function
+			lines[0] = fmt.Sprint("export default () => (", match[4])
+			lines[len(lines)-1] = strings.Replace(lines[len(lines)-1], "}", "})", 1)
+		} else {
+			lines[0] = fmt.Sprint("export default ", match[4])
+		}
+
+		return append(lines, "")
+	}
+
+	lines[0] = strings.Replace(lines[0], "export ", "", 1)
+
+	// the mutations are merged into the actions before the closing line
+	if last := strings.TrimRight(lines[len(lines)-1], " ;"); strings.HasSuffix(last, "}") {
+		lines[len(lines)-1] = fmt.Sprint(last, ";")
+	}
+
+	return append(lines, "", fmt.Sprintf("export default %s;", prop), "")
+}
+
+// usedImports returns the import lines with a name used by the lines
+func usedImports(lines []string, imports []string) []string {
+	var result = []string{}
+	var content = strings.Join(lines, "\n")
+
+	for _, line := range imports {
+		match := nuxtPattern["import"].FindStringSubmatch(line)
+
+		for _, name := range nuxtPattern["import_names"].FindAllString(match[1], -1) {
+			if name != "as" && name != "type" && wordPattern(name).FindStringSubmatch(content) != nil {
+				result = append(result, line)
+				break
+			}
+		}
+	}
+
+	if len(result) > 0 {
+		result = append(result, "")
+	}
+
+	return result
+}
+
+// usedDeclarations returns the names of the declarations used by the lines
+func usedDeclarations(lines []string, declarations [][]string) []string {
+	var names = []string{}
+	var content = strings.Join(lines, "\n")
+
+	for _, block := range declarations {
+		name := nuxtPattern["declaration"].FindStringSubmatch(block[0])[3]
+
+		if wordPattern(name).FindStringSubmatch(content) != nil {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// writeNuxtHelpers moves the declarations of the module file, shared by the
+// state, getters, mutations and actions, into the helpers file
+func writeNuxtHelpers(filename string, declarations [][]string, imports []string) error {
+	var content = []string{}
+
+	for _, block := range declarations {
+		if !strings.HasPrefix(block[0], "export ") {
+			content = append(content, fmt.Sprint("export ", block[0]))
+		} else {
+			content = append(content, block[0])
+		}
+
+		content = append(content, block[1:]...)
+		content = append(content, "")
+	}
+
+	content = append(usedImports(content, imports), content...)
+
+	return os.WriteFile(filename, []byte(strings.Join(content, "\n")), 0644)
+}
+
+// extractServerInit removes the nuxtServerInit action and writes it as a
+// nuxt plugin to be completed by hand, the action is kept when the plugin
+// can not be written
+func (mg *migration) extractServerInit(path string, actions []string, imports []string) ([]string, error) {
+	var start = slices.IndexFunc(actions, func(line string) bool {
+		return nuxtPattern["server_init"].FindStringSubmatch(line) != nil
+	})
+
+	if start < 0 {
+		return actions, nil
+	}
+
+	var end = start + 1
+	for end < len(actions) && nuxtPattern["property_end"].FindStringSubmatch(actions[end]) == nil {
+		end++
+	}
+
+	var body = []string{}
+	for _, line := range actions[start+1 : end] {
+		body = append(body, fmt.Sprint("  // ", strings.TrimPrefix(line, "    ")))
+	}
+
+	var plugin = usedImports(body, imports)
+	plugin = append(plugin, []string{
+		"export default async ({ $pinia }) => {",
+		"  // TODO migrated from nuxtServerInit, replace the commit and dispatch calls",
+		"  // with the store actions, e.g. useCartStore($pinia).load()",
+		fmt.Sprint("  // nuxtServerInit(", nuxtPattern["server_init"].FindStringSubmatch(actions[start])[2], ")"),
+		"  if (process.server) {",
+	}...)
+	plugin = append(plugin, indentLines(body, "  ")...)
+	plugin = append(plugin, "  }", "};", "")
+
+	var pluginPath = filepath.Join(filepath.Dir(mg.root), "plugins", fmt.Sprint(NUXT_SERVER_INIT, filepath.Ext(path)))
+
+	if err := os.MkdirAll(filepath.Dir(pluginPath), 0755); err != nil {
+		return actions, err
+	}
+
+	if err := os.WriteFile(pluginPath, []byte(strings.Join(plugin, "\n")), 0644); err != nil {
+		return actions, err
+	}
+
+	mg.addDiagnostic(pluginPath, 0, "nuxtServerInit is not supported by pinia, complete the plugin and register it with mode 'server' in nuxt.config")
+
+	return append(slices.Clone(actions[:start]), actions[end+1:]...), nil
+}
@@ -0,0 +1,158 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var serverInitActions = []string{
+	"export const actions = {",
+	"  async nuxtServerInit({ commit }, { req }) {",
+	"    commit('setUser', req.user);",
+	"  },",
+	"  load() {},",
+	"};",
+}
+
+func TestExtractServerInit(t *testing.T) {
+	var root = filepath.Join(t.TempDir(), "store")
+	var mg = newMigration(root, Config{Framework: NUXT2_FRAMEWORK})
+
+	actions, err := mg.extractServerInit(filepath.Join(root, "index.js"), serverInitActions, []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"export const actions = {", "  load() {},", "};"}; !slices.Equal(actions, expected) {
+		t.Errorf("extractServerInit = %q, expected %q", actions, expected)
+	}
+
+	if !fileExists(filepath.Join(filepath.Dir(root), "plugins", "nuxt-server-init.js")) {
+		t.Errorf("nuxtServerInit plugin was not written")
+	}
+}
+
+func TestExtractServerInitKeepsActionOnError(t *testing.T) {
+	var dir = t.TempDir()
+	var root = filepath.Join(dir, "store")
+	var mg = newMigration(root, Config{Framework: NUXT2_FRAMEWORK})
+
+	// the plugins directory can not be created
+	if err := os.WriteFile(filepath.Join(dir, "plugins"), []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	actions, err := mg.extractServerInit(filepath.Join(root, "index.js"), serverInitActions, []string{})
+	if err == nil {
+		t.Errorf("extractServerInit did not return the error writing the plugin")
+	}
+
+	if !slices.Equal(actions, serverInitActions) {
+		t.Errorf("extractServerInit removed nuxtServerInit without writing the plugin: %q", actions)
+	}
+}
+
+func TestNuxtPropFile(t *testing.T) {
+	var tests = []struct {
+		prop     string
+		block    []string
+		expected []string
+	}{
+		{"state", []string{"export const state = {", "  items: [],", "}"}, []string{"export default () => ({", "  items: [],", "})", ""}},
+		{"state", []string{"export const state = () => ({", "  items: [],", "})"}, []string{"export default () => ({", "  items: [],", "})", ""}},
+		{"getters", []string{"export const getters = {", "  count: (state) => state.items.length,", "}"}, []string{"const getters = {", "  count: (state) => state.items.length,", "};", "", "export default getters;", ""}},
+		{"mutations", []string{"export const mutations = {", "  ADD(state) {},", "};"}, []string{"const mutations = {", "  ADD(state) {},", "};", "", "export default mutations;", ""}},
+	}
+
+	for _, test := range tests {
+		if result := nuxtPropFile(test.prop, test.block); !slices.Equal(result, test.expected) {
+			t.Errorf("nuxtPropFile(%s, %q) = %q, expected %q", test.prop, test.block, result, test.expected)
+		}
+	}
+}
+
+func TestMigrateNuxtModules(t *testing.T) {
+	var root = filepath.Join(t.TempDir(), "store")
+	// the nuxt blocks are closed without a semicolon
+	var index = []string{
+		"export const state = () => ({",
+		"  locale: 'en',",
+		"})",
+		"",
+		"export const mutations = {",
+		"  SET_LOCALE(state, locale) {",
+		"    state.locale = locale",
+		"  },",
+		"}",
+		"",
+	}
+	var cart = []string{
+		"const LIMIT = 10;",
+		"",
+		"function normalize(items) {",
+		"  return items.slice(0, LIMIT);",
+		"}",
+		"",
+		"export const state = () => ({",
+		"  items: [],",
+		"})",
+		"",
+		"export const mutations = {",
+		"  SET_ITEMS(state, items) {",
+		"    state.items = normalize(items);",
+		"  },",
+		"}",
+		"",
+		"export const actions = {",
+		"  load({ commit }, items) {",
+		"    commit('SET_ITEMS', items);",
+		"    commit('SET_LOCALE', 'fr', { root: true });",
+		"  },",
+		"}",
+		"",
+	}
+
+	writeModule(t, root, ".", map[string]string{"index.js": strings.Join(index, "\n"), "cart.js": strings.Join(cart, "\n")})
+
+	result, err := Migrate(context.Background(), root, Config{NoCache: true, Framework: NUXT2_FRAMEWORK})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var files = map[string][]string{
+		"cart/index.js":   {"export const useCartStore = defineStore('cart', {"},
+		"cart/helpers.js": {"export const LIMIT = 10;", "export function normalize(items) {"},
+		"cart/actions.js": {
+			"import { normalize } from './helpers';",
+			"import { useRootStore } from '~/stores/root'",
+			"    rootStore.SET_LOCALE('fr');",
+			"  SET_ITEMS(items) {\n    this.items = normalize(items);\n  },",
+		},
+		"root/actions.js": {"  SET_LOCALE(locale) {\n    this.locale = locale\n  },\n};"},
+	}
+
+	for name, lines := range files {
+		content, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertContains(t, name, string(content), lines...)
+	}
+
+	if fileExists(filepath.Join(root, "cart.js")) {
+		t.Errorf("cart.js was not split into the cart store")
+	}
+
+	for _, module := range result.Modules {
+		for _, diagnostic := range module.Diagnostics {
+			if strings.Contains(diagnostic.Message, "is not namespaced") {
+				t.Errorf("root mutation reported as %q", diagnostic.Message)
+			}
+		}
+	}
+}
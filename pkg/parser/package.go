@@ -1,165 +1,256 @@
 package parser
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
 	"slices"
 	"strings"
+	"sync"
 )
 
-var (
-	Debug   = false
-	Verbose = false
-)
-
+// Module is a directory of the store with the files to translate
 type Module struct {
-	files      []string
-	dirList    []string
-	subModules map[string]string
-	path       string
-	outputDir  string
-	parentName string
+	files []string
+	path  string
+	mg    *migration
 }
 
-var (
-	subModules = []string{}
+// result of the translation of a module
+const (
+	MODULE_CREATED = "created"
+	MODULE_SKIPPED = "skipped"
+	// the module has nothing to migrate or is not part of the migration
+	MODULE_UNCHANGED = "unchanged"
+	MODULE_FAILED    = "failed"
 )
 
-func NewModule(outputDir string) Module {
-	return Module{
-		outputDir:  outputDir,
-		parentName: "",
-		subModules: make(map[string]string),
-		path:       "",
-	}
-}
+// run migrates the store directory of the migration
+func (mg *migration) run(ctx context.Context) error {
+	mg.printMemUsage()
 
-func NewSubModule(outputDir string, parentName string) Module {
-	return Module{
-		outputDir:  outputDir,
-		parentName: parentName,
-		subModules: make(map[string]string),
-		path:       "",
+	if mg.config.Force {
+		if err := mg.restoreOriginals(); err != nil {
+			return err
+		}
 	}
-}
 
-func (m *Module) Parse() error {
-	if Debug {
-		PrintMemUsage()
+	if mg.config.Lang == LANG_JS {
+		if err := mg.checkJavascriptSources(); err != nil {
+			return err
+		}
 	}
 
-	err := filepath.Walk(m.outputDir, m.walk)
-
-	if err != nil && Verbose {
-		fmt.Println("Err: ", err)
+	// the next passes rewrite the vuex files
+	if err := mg.saveSources(); err != nil {
+		return err
 	}
 
-	if Debug {
-		PrintMemUsage()
+	if mg.config.HMR {
+		if err := mg.removeHotUpdate(); err != nil {
+			return err
+		}
 	}
 
-	return err
-}
+	if err := mg.migrateClassModules(); err != nil {
+		return err
+	}
 
-func (m *Module) walk(path string, info os.FileInfo, err error) error {
-	if err != nil {
-		if Verbose {
-			fmt.Println("Err: ", err)
+	if mg.config.Framework == NUXT2_FRAMEWORK {
+		if err := mg.splitNuxtModules(); err != nil {
+			return err
 		}
-		return err
 	}
 
-	if m.outputDir == path {
-		return nil
+	// the vuex root store is kept until every module is migrated
+	if len(mg.config.Modules) == 0 {
+		if err := mg.splitRootStore(); err != nil {
+			return err
+		}
 	}
 
-	// TODO: only works for directories for now
-	if info.IsDir() {
-		return nil
+	mg.modules = mg.discoverModules()
+	mg.cycles = mg.detectCycles()
+
+	if len(mg.config.Modules) == 0 {
+		mg.persist = mg.findPersistedState()
+	} else {
+		mg.checkMigratedModules()
 	}
+	mg.printDiagnostics()
 
-	m.path = regexp.MustCompile(`(.*)\/(.*)$`).ReplaceAllString(path, "$1")
+	modules, err := mg.collectModules()
 
-	// save all file names to check later the last file in dir
-	if len(append(m.dirList, subModules...)) == 0 {
-		entries, _ := os.ReadDir(m.path)
-		for _, e := range entries {
+	if err == nil {
+		var cache *migrationCache
+		if !mg.config.NoCache {
+			cache = mg.loadCache()
+			mg.printDiagnostics()
+		}
 
-			fileInfo, err := os.Stat(strings.Join([]string{m.path, e.Name()}, "/"))
-			if err != nil {
-				// TODO skips files with errors for now
-				continue
+		var statuses []string
+		var errs []error
+		statuses, errs, err = mg.translateModules(ctx, modules, cache)
+
+		// the status is empty when the module was not translated because the
+		// migration was cancelled
+		for index, module := range modules {
+			var modulePath = mg.modulePathOf(module.files[0])
+			var name = modulePath
+			if name == "." {
+				name = filepath.Base(module.path)
 			}
 
-			if fileInfo.IsDir() {
-				subModules = append(subModules, e.Name())
-			} else {
-				m.dirList = append(m.dirList, e.Name())
+			switch statuses[index] {
+			case MODULE_CREATED:
+				mg.printf("Created %s store", name)
+			case MODULE_SKIPPED:
+				mg.printf("Skipped %s store, already migrated (use --force to migrate it again)", name)
+			case MODULE_FAILED:
+				mg.printf("Failed %s store: %s", name, errs[index])
 			}
+
+			mg.result.Modules = append(mg.result.Modules, ModuleResult{
+				Path:        modulePath,
+				Status:      statuses[index],
+				Err:         errs[index],
+				Diagnostics: mg.printModuleDiagnostics(module.path),
+			})
+		}
+
+		if err == nil && cache != nil {
+			err = cache.save(mg.root)
+		}
+
+		// the vuex root store is kept until every module is migrated
+		if err == nil && len(mg.config.Modules) == 0 {
+			err = mg.migratePlugins()
 		}
+
+		if err == nil {
+			err = mg.lazyStoreInstances()
+		}
+
+		mg.printDiagnostics()
 	}
 
-	modName := regexp.MustCompile(`(.*)\/(.*)\/(.*)$`).ReplaceAllString(path, "$2")
+	mg.printErr(err)
+	mg.printMemUsage()
+
+	return err
+}
+
+// collectModules returns the directories of the store with their files,
+// sorted by path so parents come before their sub modules
+func (mg *migration) collectModules() ([]Module, error) {
+	var modules = []Module{}
+
+	err := filepath.WalkDir(mg.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if isOriginalsDir(entry) {
+			return filepath.SkipDir
+		}
+
+		if !entry.IsDir() {
+			return nil
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return err
+		}
+
+		var module = Module{path: path, mg: mg}
+		for _, e := range entries {
+			fileInfo, err := os.Stat(filepath.Join(path, e.Name()))
+			if err != nil || fileInfo.IsDir() || e.Name() == CACHE_FILENAME {
+				// TODO skips files with errors for now
+				continue
+			}
+
+			module.files = append(module.files, filepath.Join(path, e.Name()))
+		}
 
-	if slices.Contains(subModules, modName) {
-		_, ok := m.subModules[modName]
-		if !ok {
-			m.subModules[modName] = regexp.MustCompile(`(.*)\/(.*)$`).ReplaceAllString(path, "$1")
+		if len(module.files) > 0 {
+			modules = append(modules, module)
 		}
 
 		return nil
+	})
+
+	return modules, err
+}
+
+// translateModules translates the modules with a pool of workers, the
+// statuses and errors are returned in the order of the modules, the status
+// is empty for the modules not translated when the context is cancelled.
+// The modules found in the cache are not translated again
+func (mg *migration) translateModules(ctx context.Context, modules []Module, cache *migrationCache) ([]string, []error, error) {
+	var statuses = make([]string, len(modules))
+	var errs = make([]error, len(modules))
+	var indexes = make(chan int)
+	var group sync.WaitGroup
+
+	var workers = min(mg.config.Jobs, len(modules))
+	// the verbose output is printed while parsing
+	if mg.config.Verbose || mg.config.Debug {
+		workers = min(1, len(modules))
 	}
 
-	// add files to current module
-	m.files = append(m.files, path)
+	for worker := 0; worker < workers; worker++ {
+		group.Add(1)
 
-	// checking last file inside the directory
-	if m.dirList[len(m.dirList)-1] == info.Name() {
+		go func() {
+			defer group.Done()
 
-		printOutput(path, func() {
-			migrated := m.translate()
+			for index := range indexes {
+				var module = modules[index]
 
-			if migrated {
-				if m.parentName == "" {
-					fmt.Printf("Created %s store\n", modName)
-				} else {
-					fmt.Printf("Created %s store\n", strings.Join([]string{m.parentName, modName}, "/"))
-				}
-			}
-		})
-
-		// clean current module files
-		m.files = []string{}
-		// clean files in dir
-		m.dirList = []string{}
-		// clean sub modules
-		subModules = []string{}
-
-		// when finishes current module, parse sub modules
-		for _, subModPath := range m.subModules {
-			var parentName string
-
-			if m.parentName == "" {
-				parentName = strings.Join([]string{modName}, "/")
-			} else {
-				parentName = strings.Join([]string{m.parentName, modName}, "/")
+				mg.printOutput(module.files[0], func() {
+					if cache != nil {
+						statuses[index], errs[index] = module.translateCached(cache)
+					} else {
+						statuses[index], errs[index] = module.translate()
+					}
+				})
 			}
+		}()
+	}
 
-			var subModule = NewSubModule(subModPath, parentName)
+	var err error
 
-			subModule.Parse()
+	for index := range modules {
+		if err = ctx.Err(); err != nil {
+			break
 		}
 
-		m.subModules = make(map[string]string)
+		indexes <- index
+	}
+
+	close(indexes)
+	group.Wait()
+
+	for index := range modules {
+		if errs[index] != nil {
+			statuses[index] = MODULE_FAILED
+
+			if err == nil {
+				err = fmt.Errorf("%s: %w", modules[index].path, errs[index])
+			}
+		}
 	}
 
-	return nil
+	return statuses, errs, err
 }
 
-func (m *Module) translate() bool {
+func (m *Module) translate() (string, error) {
+	var mg = m.mg
 	filesMap := make(map[string]*os.File) // will have actions, mutations, state, getters keys
 
 	// open and save files to the map
@@ -174,21 +265,83 @@ func (m *Module) translate() bool {
 		filesMap[filename] = file
 	}
 
-	actionsPath, _ := checkActionsFile(filesMap)
+	var modulePath = ""
+	for _, file := range filesMap {
+		modulePath = mg.modulePathOf(file.Name())
+	}
+
+	if !mg.isMigrated(modulePath) {
+		return MODULE_UNCHANGED, nil
+	}
+
+	if isPiniaModule(filesMap) {
+		// the store was translated from a class by this run
+		if !mg.piniaModules[modulePath] {
+			return MODULE_CREATED, nil
+		}
+
+		if mg.config.Force {
+			mg.addDiagnostic(filesMap["index"].Name(), 0, "the vuex files of %s were not found in %s, the store is kept", modulePath, ORIGINALS_DIR)
+			return MODULE_UNCHANGED, nil
+		}
+
+		return MODULE_SKIPPED, nil
+	}
+
+	var lang = mg.moduleLang(filesMap)
+	if lang == LANG_TS {
+		if err := convertToTypescript(filesMap); err != nil {
+			return "", err
+		}
+
+		for _, file := range filesMap {
+			defer file.Close()
+		}
+	}
+
+	actionsPath, err := checkActionsFile(filesMap, lang)
+	if err != nil {
+		return "", err
+	}
+
 	if actionsPath != "" {
-		file, _ := os.Open(actionsPath)
+		file, err := os.Open(actionsPath)
+		if err != nil {
+			return "", err
+		}
+
 		filesMap["actions"] = file
 
 		defer file.Close()
 	}
 
-	var mutationsLines, mutationsImportLines = parseMutations(filesMap)
-	var actionsLines = parseActions(filesMap)
-	var gettersLines = parseGetters(filesMap)
+	mutationsLines, mutationsImportLines, err := mg.parseMutations(filesMap)
+	if err != nil {
+		return "", err
+	}
+
+	actionsLines, callsByName, err := mg.parseActions(filesMap)
+	if err != nil {
+		return "", err
+	}
+
+	gettersLines, err := mg.parseGetters(filesMap)
+	if err != nil {
+		return "", err
+	}
+
 	var migrated = []string{}
 
-	appendLinesToObj(&actionsLines, &mutationsLines)
+	if !appendLinesToObj(&actionsLines, &mutationsLines) {
+		return "", fmt.Errorf("the mutations of %s can not be merged into its actions", modulePath)
+	}
+
 	appendImports(&actionsLines, &mutationsImportLines)
+	removeVueImport(&actionsLines)
+
+	if file, ok := filesMap["actions"]; ok && callsByName && strings.HasSuffix(file.Name(), ".ts") {
+		addActionNameType(&actionsLines)
+	}
 
 	// get actions file to write lines
 	file, ok := filesMap["actions"]
@@ -196,7 +349,7 @@ func (m *Module) translate() bool {
 		// write actions into output file
 		err := os.WriteFile(file.Name(), []byte(strings.Join(actionsLines, "\n")), 0644)
 		if err != nil {
-			log.Fatal(err)
+			return "", err
 		}
 
 		migrated = append(migrated, "actions")
@@ -208,7 +361,7 @@ func (m *Module) translate() bool {
 		// write getters into output file
 		err := os.WriteFile(file.Name(), []byte(strings.Join(gettersLines, "\n")), 0644)
 		if err != nil {
-			log.Fatal(err)
+			return "", err
 		}
 
 		migrated = append(migrated, "getters")
@@ -227,8 +380,9 @@ func (m *Module) translate() bool {
 		migrated = append(migrated, "mutations")
 	}
 
-	if len(migrated) == 0 {
-		return false
+	// the modules with only state are stores too, e.g. the root module
+	if _, ok := filesMap["state"]; !ok && len(migrated) == 0 {
+		return MODULE_UNCHANGED, nil
 	}
 
 	// set template type for index file
@@ -241,24 +395,72 @@ func (m *Module) translate() bool {
 		templateType = NO_GETTERS_TEMPLATE
 	}
 
+	var storeName = ""
+	for _, file := range filesMap {
+		storeName = filepath.Base(filepath.Dir(file.Name()))
+	}
+
+	if file, ok := filesMap["state"]; ok && strings.HasSuffix(file.Name(), ".js") {
+		var stateType = fmt.Sprint(kebabToCamelCase(storeName, true), "State")
+
+		if mg.config.Lang == LANG_JS {
+			if err := mg.jsdocStateFile(file.Name(), stateType); err != nil {
+				return "", err
+			}
+		} else if mg.config.TypedState || mg.config.Lang == LANG_TS {
+			statePath, err := mg.typeStateFile(file.Name(), stateType)
+			if err != nil {
+				return "", err
+			}
+
+			if statePath != "" {
+				// the store is typed by the state
+				lang = LANG_TS
+			} else if mg.config.Lang == LANG_TS {
+				if err := os.Rename(file.Name(), fmt.Sprint(strings.TrimSuffix(file.Name(), ".js"), ".ts")); err != nil {
+					return "", err
+				}
+			}
+		}
+	}
+
 	// create module entrypoint
-	var templatePath = getTemplatePath(filesMap, "index")
-	var storeName = strings.Split(templatePath, "/")[len(strings.Split(templatePath, "/"))-2]
+	var templatePath = getTemplatePath(filesMap, "index", lang)
+	if file, ok := filesMap["index"]; ok && file.Name() != templatePath {
+		os.Remove(file.Name())
+	}
 	var values = map[string]string{
 		"storeName":          storeName,
 		"storeNameTitleCase": kebabToCamelCase(storeName, true),
+		"persist":            mg.persist[mg.modulePathOf(templatePath)],
+	}
+
+	if mg.config.HMR {
+		values["hmr"] = "true"
 	}
 
-	err := createTemplate(templateType, templatePath, values)
+	if len(mg.config.Modules) > 0 {
+		values["bridge"] = BRIDGE_FILENAME
+	}
+
+	if err := createTemplate(templateType, templatePath, values); err != nil {
+		return "", err
+	}
 
-	return err == nil
+	if len(mg.config.Modules) > 0 {
+		if _, err := mg.writeBridge(filepath.Dir(templatePath), modulePath, lang); err != nil {
+			return "", err
+		}
+	}
+
+	return MODULE_CREATED, nil
 }
 
-func checkActionsFile(filesMap map[string]*os.File) (string, error) {
+func checkActionsFile(filesMap map[string]*os.File, lang string) (string, error) {
 	_, actionsFileOk := filesMap["actions"]
 	_, mutationsFileOk := filesMap["mutations"]
 	if !actionsFileOk && mutationsFileOk {
-		var templatePath = strings.Replace(getTemplatePath(filesMap, "index"), "index", "actions", 1)
+		var templatePath = getTemplatePath(filesMap, "actions", lang)
 
 		// create actions file
 		err := createTemplate(ACTIONS_EMPTY_TEMPLATE, templatePath, map[string]string{})
@@ -272,19 +474,24 @@ func checkActionsFile(filesMap map[string]*os.File) (string, error) {
 	return "", nil
 }
 
-func appendLinesToObj(lines *[]string, linesToAppend *[]string) {
-	const CLOSE_OBJ_LINE = `^\};$`
+// appendLinesToObj inserts the lines at the end of the object closed by the
+// last `};` line, or the last `}` line when the object is closed without a
+// semicolon, reporting if the lines were inserted
+func appendLinesToObj(lines *[]string, linesToAppend *[]string) bool {
 	const OBJ_START_LINE = `const\s\w+(:\s\w+)?\s=\s{$`
 	const CLOSE_FUNCTION_CURLY_BRACE = "  },"
 
 	if len(*linesToAppend) == 0 {
-		return
+		return true
 	}
 
-	// start from the last line
-	for index := len(*lines) - 1; index >= 0; index-- {
-		// search latest line after close the object
-		if regexp.MustCompile(CLOSE_OBJ_LINE).FindStringSubmatch((*lines)[index]) != nil {
+	for _, closeObjLine := range []string{`^\};$`, `^\}$`} {
+		// start from the last line
+		for index := len(*lines) - 1; index >= 2; index-- {
+			// search latest line after close the object
+			if regexp.MustCompile(closeObjLine).FindStringSubmatch((*lines)[index]) == nil {
+				continue
+			}
 
 			if regexp.MustCompile(OBJ_START_LINE).FindStringSubmatch((*lines)[index-2]) == nil {
 				// this fixes the last line adding a comma at the end of it
@@ -298,9 +505,11 @@ func appendLinesToObj(lines *[]string, linesToAppend *[]string) {
 				*lines = insertLine(*lines, index+(lineIndex*2+1), line)
 			}
 
-			break
+			return true
 		}
 	}
+
+	return false
 }
 
 func appendImports(lines *[]string, importLines *[]string) {
@@ -309,6 +518,7 @@ func appendImports(lines *[]string, importLines *[]string) {
 
 	var replacedImports = []int{}
 	var lastImportIndex = 0
+	var hasImports = false
 
 	if len(*importLines) == 0 || len(*lines) == 0 {
 		return
@@ -326,11 +536,25 @@ func appendImports(lines *[]string, importLines *[]string) {
 
 				if importMatch := replaceImportPattern.FindStringSubmatch(importLine); importMatch != nil {
 
+					// same import statement already added
+					if strings.TrimSuffix(importLine, ";") == strings.TrimSuffix(line, ";") {
+						replacedImports = append(replacedImports, importIndex)
+						continue
+					}
+
 					// check same file imported and is not a default import
-					if importMatch[4] == match[4] && importMatch[2] == "" {
-						// remove mutations related import
-						importValue := namedValuePattern.ReplaceAllString(importMatch[3], "")
-						line := replaceImportPattern.ReplaceAllString(line, fmt.Sprintf("import {$3,%s} from $4$7", importValue))
+					if importMatch[4] == match[4] && importMatch[2] == "" && match[2] == "" {
+						var values = splitProps(match[3])
+
+						// remove mutations related import and values already imported
+						for _, value := range splitProps(namedValuePattern.ReplaceAllString(importMatch[3], "")) {
+							if !slices.Contains(values, value) {
+								values = append(values, value)
+							}
+						}
+
+						line = fmt.Sprintf("import { %s } from %s%s", strings.Join(values, ", "), match[4], match[7])
+						match = replaceImportPattern.FindStringSubmatch(line)
 
 						(*lines)[index] = line
 						replacedImports = append(replacedImports, importIndex)
@@ -339,6 +563,7 @@ func appendImports(lines *[]string, importLines *[]string) {
 			}
 
 			lastImportIndex = index
+			hasImports = true
 		}
 
 		if len(replacedImports) == len(*importLines) {
@@ -347,6 +572,11 @@ func appendImports(lines *[]string, importLines *[]string) {
 	}
 
 	if len(replacedImports) != len(*importLines) {
+		if !hasImports {
+			// separate the imports from the code
+			*lines = insertLine(*lines, 0, "")
+		}
+
 		for lineIndex, line := range *importLines {
 			if slices.Contains(replacedImports, lineIndex) {
 				continue
@@ -360,3 +590,30 @@ func appendImports(lines *[]string, importLines *[]string) {
 		}
 	}
 }
+
+// addActionNameType declares the union of the action names after the
+// imports, used to type actions called by name, e.g. `this[name]()`
+func addActionNameType(lines *[]string) {
+	var importPattern = regexp.MustCompile(`^import\s`)
+	var names = []string{}
+	var lastImportIndex = -1
+
+	for index, line := range *lines {
+		if importPattern.FindStringSubmatch(line) != nil {
+			lastImportIndex = index
+		}
+
+		if match := modulePattern["function_name"].FindStringSubmatch(line); match != nil {
+			names = append(names, fmt.Sprintf("'%s'", match[2]))
+		}
+	}
+
+	if len(names) == 0 {
+		return
+	}
+
+	var typeLine = fmt.Sprintf("type %s = %s;", ACTION_NAME_TYPE, strings.Join(names, " | "))
+
+	*lines = insertLine(*lines, lastImportIndex+1, "")
+	*lines = insertLine(*lines, lastImportIndex+2, typeLine)
+}
@@ -0,0 +1,204 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// brokenSource creates a source file which can not be read, the link points
+// to a directory
+func brokenSource(t *testing.T, root string, name string) {
+	var dir = filepath.Join(t.TempDir(), "dir")
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(dir, filepath.Join(root, name)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// files of a module migrated by a previous run
+var piniaModule = map[string]string{"index.js": "export const useUserStore = defineStore('user', {});\n"}
+
+func TestRunStopsOnPassErrors(t *testing.T) {
+	var tests = []struct {
+		name   string
+		config Config
+		setup  func(t *testing.T, root string)
+	}{
+		{"sources", Config{}, func(t *testing.T, root string) {
+			brokenSource(t, root, "index.js")
+		}},
+		// the sources of the modules migrated by a previous run are not saved,
+		// the broken file is read by the pass
+		{"class modules", Config{}, func(t *testing.T, root string) {
+			writeModule(t, root, "user", piniaModule)
+			brokenSource(t, root, "user/broken.ts")
+		}},
+		{"nuxt modules", Config{Framework: NUXT2_FRAMEWORK}, func(t *testing.T, root string) {
+			writeModule(t, root, "user", piniaModule)
+			brokenSource(t, root, "user/state.js")
+		}},
+		{"hot update", Config{HMR: true}, func(t *testing.T, root string) {
+			writeModule(t, root, "user", piniaModule)
+			brokenSource(t, root, "user/broken.js")
+		}},
+		{"originals", Config{Force: true}, func(t *testing.T, root string) {
+			// the module directory was replaced by a file
+			if err := saveOriginals(root, "cart", []string{}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.WriteFile(filepath.Join(root, ORIGINALS_DIR, "cart", "state.js"), []byte(""), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.WriteFile(filepath.Join(root, "cart"), []byte(""), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+
+	for _, test := range tests {
+		var root = t.TempDir()
+		test.setup(t, root)
+
+		if _, err := Migrate(context.Background(), root, test.config); err == nil {
+			t.Errorf("%s: migration completed without the error of the pass", test.name)
+		}
+	}
+}
+
+func TestMigrateReportsFailedModules(t *testing.T) {
+	var root = t.TempDir()
+	writeModule(t, root, "user", cartModule)
+	writeModule(t, root, "cart", map[string]string{
+		"state.js":     cartModule["state.js"],
+		"mutations.js": cartModule["mutations.js"],
+	})
+
+	// the actions file created for the mutations can not be written
+	if err := os.MkdirAll(filepath.Join(root, "cart", "actions.js"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Migrate(context.Background(), root, Config{NoCache: true})
+	if err == nil {
+		t.Fatal("migration completed without the error of the cart module")
+	}
+
+	var statuses = map[string]ModuleResult{}
+	for _, module := range result.Modules {
+		statuses[module.Path] = module
+	}
+
+	if cart := statuses["cart"]; cart.Status != MODULE_FAILED || cart.Err == nil {
+		t.Errorf("cart module reported as %q (%v)", cart.Status, cart.Err)
+	}
+
+	if user := statuses["user"]; user.Status != MODULE_CREATED || user.Err != nil {
+		t.Errorf("user module reported as %q (%v)", user.Status, user.Err)
+	}
+}
+
+func TestMigrateReportsCancelledModules(t *testing.T) {
+	var root = t.TempDir()
+	writeModule(t, root, "cart", cartModule)
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	result, err := Migrate(ctx, root, Config{NoCache: true})
+	if err == nil {
+		t.Fatal("cancelled migration completed")
+	}
+
+	if len(result.Modules) != 1 || result.Modules[0].Status != "" {
+		t.Errorf("modules reported as %+v", result.Modules)
+	}
+}
+
+func TestMigrateReportsModulesInOrder(t *testing.T) {
+	var root = t.TempDir()
+	var names = []string{"account", "billing", "cart", "orders", "products", "user"}
+
+	for _, name := range names {
+		writeModule(t, root, name, cartModule)
+	}
+
+	result, err := Migrate(context.Background(), root, Config{NoCache: true, Jobs: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Modules) != len(names) {
+		t.Fatalf("modules reported as %+v", result.Modules)
+	}
+
+	for index, module := range result.Modules {
+		if module.Path != names[index] || module.Status != MODULE_CREATED {
+			t.Errorf("module %d reported as %s %q", index, module.Path, module.Status)
+		}
+	}
+}
+
+func TestMigrateKeepsUnmergedMutations(t *testing.T) {
+	var root = t.TempDir()
+	writeModule(t, root, "cart", map[string]string{
+		"state.js":     cartModule["state.js"],
+		"mutations.js": cartModule["mutations.js"],
+		// the actions are not declared in the file
+		"actions.js": "import actions from './shared';\n\nexport default actions;\n",
+	})
+
+	result, err := Migrate(context.Background(), root, Config{NoCache: true})
+	if err == nil {
+		t.Fatal("migration completed without merging the mutations")
+	}
+
+	if len(result.Modules) != 1 || result.Modules[0].Status != MODULE_FAILED {
+		t.Errorf("modules reported as %+v", result.Modules)
+	}
+
+	if !fileExists(filepath.Join(root, "cart", "mutations.js")) {
+		t.Errorf("mutations file removed without merging the mutations")
+	}
+}
+
+func TestAppendLinesToObj(t *testing.T) {
+	var tests = []struct {
+		lines    []string
+		expected []string
+	}{
+		{
+			[]string{"const actions = {", "", "};", "", "export default actions;"},
+			[]string{"const actions = {", "", "", "  add() {},", "};", "", "export default actions;"},
+		},
+		{
+			[]string{"export default {", "  load() {", "  }", "}"},
+			[]string{"export default {", "  load() {", "  },", "", "  add() {},", "}"},
+		},
+		{
+			[]string{"const actions = {", "  load() {", "  },", "};", "", "function helper() {", "  return 1;", "}"},
+			[]string{"const actions = {", "  load() {", "  },", "", "  add() {},", "};", "", "function helper() {", "  return 1;", "}"},
+		},
+	}
+
+	for _, test := range tests {
+		var lines = slices.Clone(test.lines)
+
+		if !appendLinesToObj(&lines, &[]string{"  add() {},"}) || !slices.Equal(lines, test.expected) {
+			t.Errorf("appendLinesToObj(%q) = %q", test.lines, lines)
+		}
+	}
+
+	var lines = []string{"export default actions;"}
+	if appendLinesToObj(&lines, &[]string{"  add() {},"}) {
+		t.Errorf("appendLinesToObj merged the lines without an object")
+	}
+}
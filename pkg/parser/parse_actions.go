@@ -3,45 +3,54 @@ package parser
 import (
 	"bufio"
 	"fmt"
-	"log"
+	"maps"
 	"os"
 	"regexp"
-	"slices"
 	"strings"
 )
 
 var actionPattern = map[string]*regexp.Regexp{
-	string("function"):                  regexp.MustCompile(`\b(\w+)\((\{[\w\s\,]+\}|\w+)((,\s*(.*))\)|\))((\:\s.+)?\s{)$`),
+	string("function"):                  regexp.MustCompile(`\b(\w+)\((\{[\w\s\,\:]+\}|\w+)((,\s*(.*))\)|\))((\:\s.+)?\s{)$`),
 	string("commit_dispatch"):           regexp.MustCompile(`\b(commit|dispatch)\(["|'](.+?)["|'],?\s?(.*)\)`),
 	string("state_prop"):                regexp.MustCompile(`(state\.)(\w+)`),
 	string("commit_dispatch_lines"):     regexp.MustCompile(`\b(dispatch|commit)\('(.*)',\s(\{)$|\b(dispatch|commit)\($`),
 	string("commit_dispatch_lines_end"): regexp.MustCompile(`.*\);$`),
 	string("function_lines"):            regexp.MustCompile(`^\s{2}(async\s)?(\w)+\($`),
+	string("action_start"):              regexp.MustCompile(`^\s{2}(async\s)?\w+\(`),
 	string("function_lines_end"):        regexp.MustCompile(`\s{2}\)\s{$`),
 	string("getter_call"):               regexp.MustCompile(`getters\.(\w*)`),
 	string("import_store"):              regexp.MustCompile(`~/store/`),
 	string("line_comment"):              regexp.MustCompile(`(//.*)|(/\*.*\*\/)`),
+	string("context_destructuring"):     regexp.MustCompile(`^\s*(const|let)\s\{([\w\s\,\:]+)\}\s=\s(\w+);?$`),
 }
 
-func parseActions(filesMap map[string]*os.File) []string {
+// context properties that are available as store members or helpers once
+// the `context.` prefix is removed
+var contextProps = []string{"commit", "dispatch", "state", "getters", "rootState", "rootGetters"}
+
+func (mg *migration) parseActions(filesMap map[string]*os.File) ([]string, bool, error) {
 	file, ok := filesMap["actions"]
 	if !ok {
-		return []string{}
+		return []string{}, false, nil
 	}
 
-	if Verbose {
-		fmt.Printf("parsing: %s\n", file.Name())
-	}
-	scanner := bufio.NewScanner(file)
+	mg.verbosef("parsing: %s", file.Name())
+	scanner := bufio.NewScanner(mg.normalizeFunctions(file))
 
 	var lines []string
 	var multiLineAction = []string{}
 	var multiLineFnCall = []string{}
-	var importedStores = []string{}
-	var intantiatedStores = []string{}
+	var stores = crossStores{mg: mg}
+	var instancesIndex = 0
+	var callsByName = false
+	var currentPath = mg.modulePathOf(file.Name())
+	var contextName = ""
+	var contextAliases = map[string]string{}
+	var lineNumber = 0
 
 	for scanner.Scan() {
 		line := scanner.Text()
+		lineNumber++
 
 		if actionPattern["import_store"].FindStringSubmatch(line) != nil {
 			line = actionPattern["import_store"].ReplaceAllString(line, "~/stores/")
@@ -67,19 +76,38 @@ func parseActions(filesMap map[string]*os.File) []string {
 		}
 
 		if match := actionPattern["function"].FindStringSubmatch(line); match != nil {
+			if actionPattern["action_start"].FindStringSubmatch(line) != nil {
+				// keep track of the context param of the current action
+				contextName, contextAliases = parseContextParam(match[2])
+
+				// stores are instantiated after the action definition line
+				instancesIndex = len(lines) + 1
+				stores.reset()
+			}
+
 			line = actionPattern["function"].ReplaceAllString(line, "$1($5)$6")
+		}
 
-			intantiatedStores = []string{}
+		if contextName != "" || len(contextAliases) > 0 {
+			if match := actionPattern["context_destructuring"].FindStringSubmatch(line); match != nil && match[3] == contextName {
+				// remove `const { commit } = context` lines, keeping renamed props
+				_, aliases := parseContextParam(fmt.Sprintf("{%s}", match[2]))
+				maps.Copy(contextAliases, aliases)
+
+				continue
+			}
+
+			line = replaceContextUses(line, contextName, contextAliases)
+
+			if contextName != "" && wordPattern(contextName).FindStringSubmatch(line) != nil {
+				mg.addDiagnostic(file.Name(), lineNumber, "action context '%s' can not be translated, use the store instance instead", contextName)
+			}
 		}
 
 		if actionPattern["state_prop"].FindStringSubmatch(line) != nil {
 			line = actionPattern["state_prop"].ReplaceAllString(line, "this.$2")
 		}
 
-		if match := getterPattern["getter_call"].FindStringSubmatch(line); match != nil {
-			line = getterPattern["getter_call"].ReplaceAllString(line, "this.$1")
-		}
-
 		if actionPattern["commit_dispatch_lines"].FindStringSubmatch(line) != nil && len(multiLineFnCall) == 0 {
 			multiLineFnCall = append(multiLineFnCall, strings.TrimSpace(line))
 
@@ -97,47 +125,137 @@ func parseActions(filesMap map[string]*os.File) []string {
 			}
 		}
 
+		var defLines []string
+		line, defLines = stores.replaceGlobalGetters(line, currentPath)
+
+		var rootDefLines []string
+		line, rootDefLines = stores.replaceRootRefs(line)
+		defLines = append(defLines, rootDefLines...)
+
+		if match := getterPattern["getter_call"].FindStringSubmatch(line); match != nil {
+			line = getterPattern["getter_call"].ReplaceAllString(line, "this.$1")
+		}
+
+		if match := getterPattern["getter_key_call"].FindStringSubmatch(line); match != nil {
+			line = getterPattern["getter_key_call"].ReplaceAllString(line, "this.$2")
+		}
+
 		if match := actionPattern["commit_dispatch"].FindStringSubmatch(line); match != nil {
 
-			if strings.Contains(match[3], "root: true") {
-				// should import another store
+			var root = strings.Contains(match[3], "root: true")
+			var modulePath = ""
+			var fnName = match[2]
+
+			if root && strings.Contains(match[2], "/") {
 				fn := strings.Split(match[2], "/")
-				storeFn := fmt.Sprintf("use%sStore", capitalizeByteSlice(fn[0]))
-				storeName := fmt.Sprint(fn[0], "Store")
-				fnName := fn[1]
-				args := strings.Replace(match[3], ", { root: true }", "", 1)
-				args = strings.Replace(args, ",{ root: true }", "", 1)
-
-				line = actionPattern["commit_dispatch"].ReplaceAllString(line, fmt.Sprintf("%s.%s(%s)", storeName, fnName, args))
-
-				if !slices.Contains(intantiatedStores, storeName) {
-					// get instance of root store
-					line = fmt.Sprintf("\t\tconst %s = %s()\n%s", storeName, storeFn, line)
-					intantiatedStores = append(intantiatedStores, storeName)
+				fnName = fn[len(fn)-1]
+				modulePath = mg.resolveNamespace(strings.Join(fn[:len(fn)-1], "/"))
+			} else if owners := mg.globalOwners(match[2], false, currentPath, root); len(owners) > 0 {
+				// modules without namespace share actions and mutations
+				modulePath = owners[0]
+
+				if len(owners) > 1 {
+					mg.addDiagnostic(file.Name(), lineNumber, "'%s' is defined by the modules %s, only the first one is called", match[2], strings.Join(owners, ", "))
 				}
+			} else if root {
+				mg.addDiagnostic(file.Name(), lineNumber, "'%s' is not namespaced, it can not be bound to a store", match[2])
+			}
 
-				if !slices.Contains(importedStores, storeName) {
-					// create import statement of store
-					importLine := fmt.Sprintf("import { %s } from '%s'", storeFn, fmt.Sprint("~/stores/", fn[0]))
+			if modulePath != "" && modulePath != currentPath && !mg.isMigrated(modulePath) {
+				// the call goes through the vuex store
+				if defLine := stores.keepVuex(modulePath); defLine != "" {
+					defLines = append(defLines, defLine)
+				}
+			} else {
+				if modulePath != "" && modulePath != currentPath {
+					// should import another store
+					args := strings.Replace(match[3], ", { root: true }", "", 1)
+					args = strings.Replace(args, ",{ root: true }", "", 1)
 
-					// add import statement to first line
-					lines = append([]string{importLine}, lines...)
-					importedStores = append(importedStores, storeName)
+					storeName, defLine := stores.use(modulePath)
+					if defLine != "" {
+						defLines = append(defLines, defLine)
+					}
+
+					line = actionPattern["commit_dispatch"].ReplaceAllString(line, fmt.Sprintf("%s.%s(%s)", storeName, fnName, args))
+				} else {
+					line = actionPattern["commit_dispatch"].ReplaceAllString(line, "this.$2($3)")
 				}
 
-			} else {
 				line = actionPattern["commit_dispatch"].ReplaceAllString(line, "this.$2($3)")
 			}
+		}
+
+		var dynamicDefLines, untranslated []string
+		var byName bool
+
+		line, dynamicDefLines, byName, untranslated = translateDynamicCalls(line, &stores, strings.HasSuffix(file.Name(), ".ts"))
+		defLines = append(defLines, dynamicDefLines...)
+		callsByName = callsByName || byName
+
+		for _, target := range untranslated {
+			mg.addDiagnostic(file.Name(), lineNumber, "commit or dispatch target %s can not be typed or bound to a store", target)
+		}
 
-			line = actionPattern["commit_dispatch"].ReplaceAllString(line, "this.$2($3)")
+		// get instances of root stores at the beginning of the action
+		for _, defLine := range defLines {
+			lines = insertLine(lines, instancesIndex, defLine)
+			instancesIndex++
 		}
 
 		lines = append(lines, line)
 	}
 
+	// add import statements of stores, merged with the existing ones
+	appendImports(&lines, &stores.imports)
+
 	if err := scanner.Err(); err != nil {
-		log.Fatal(err)
+		return nil, false, err
+	}
+
+	return lines, callsByName, nil
+}
+
+// parseContextParam returns the name of the context param of an action, or
+// the renamed props when the context is destructured, e.g. `{ commit: c }`
+func parseContextParam(param string) (string, map[string]string) {
+	var aliases = map[string]string{}
+
+	param = strings.TrimSpace(param)
+
+	if !strings.HasPrefix(param, "{") {
+		// unused params are prefixed with an underscore
+		if strings.HasPrefix(param, "_") {
+			return "", aliases
+		}
+
+		return param, aliases
+	}
+
+	for _, prop := range strings.Split(strings.Trim(param, "{}"), ",") {
+		key, alias, ok := strings.Cut(prop, ":")
+		if !ok {
+			continue
+		}
+
+		aliases[strings.TrimSpace(alias)] = strings.TrimSpace(key)
+	}
+
+	return "", aliases
+}
+
+// replaceContextUses removes the context prefix, `context.commit(...)` turns
+// into `commit(...)`, and restores the original name of renamed props
+func replaceContextUses(line string, contextName string, aliases map[string]string) string {
+	if contextName != "" {
+		pattern := regexp.MustCompile(fmt.Sprintf(`\b%s\.(%s)\b`, regexp.QuoteMeta(contextName), strings.Join(contextProps, "|")))
+
+		line = pattern.ReplaceAllString(line, "$1")
+	}
+
+	for alias, prop := range aliases {
+		line = wordPattern(alias).ReplaceAllString(line, fmt.Sprint("${1}", prop))
 	}
 
-	return lines
+	return line
 }
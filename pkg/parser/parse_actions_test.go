@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeModule writes the files of a module into the store directory
+func writeModule(t *testing.T, root string, modulePath string, files map[string]string) {
+	var dir = filepath.Join(root, modulePath)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+var cartModule = map[string]string{
+	"state.js":     "export default () => ({\n  items: [],\n});\n",
+	"getters.js":   "export default {\n  count: (state) => state.items.length,\n};\n",
+	"mutations.js": "export default {\n  add(state, item) {\n    state.items.push(item);\n  },\n};\n",
+	"actions.js":   "export default {\n  add({ commit }, item) {\n    commit('add', item);\n  },\n};\n",
+}
+
+// migratedModule migrates a store with the cart module and returns the
+// content of its files once migrated
+func migratedModule(t *testing.T, files map[string]string, config Config) map[string]string {
+	var root = t.TempDir()
+	var module = maps.Clone(cartModule)
+	maps.Copy(module, files)
+
+	writeModule(t, root, "cart", module)
+
+	config.NoCache = true
+	if _, err := Migrate(context.Background(), root, config); err != nil {
+		t.Fatal(err)
+	}
+
+	var migrated = map[string]string{}
+
+	entries, err := os.ReadDir(filepath.Join(root, "cart"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(root, "cart", entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		migrated[entry.Name()] = string(content)
+	}
+
+	return migrated
+}
+
+// assertContains checks that the migrated file contains every line
+func assertContains(t *testing.T, name string, content string, lines ...string) {
+	t.Helper()
+
+	for _, line := range lines {
+		if !strings.Contains(content, line) {
+			t.Errorf("%s does not contain %q:\n%s", name, line, content)
+		}
+	}
+}
+
+func TestParseContextParam(t *testing.T) {
+	var tests = []struct {
+		param   string
+		name    string
+		aliases map[string]string
+	}{
+		{"context", "context", map[string]string{}},
+		{"_context", "", map[string]string{}},
+		{"{ commit, state }", "", map[string]string{}},
+		{"{ commit: save, dispatch }", "", map[string]string{"save": "commit"}},
+	}
+
+	for _, test := range tests {
+		name, aliases := parseContextParam(test.param)
+		if name != test.name || !maps.Equal(aliases, test.aliases) {
+			t.Errorf("parseContextParam(%q) = %q, %v", test.param, name, aliases)
+		}
+	}
+}
+
+func TestReplaceContextUses(t *testing.T) {
+	var tests = []struct {
+		line     string
+		name     string
+		aliases  map[string]string
+		expected string
+	}{
+		{"context.commit('add', item);", "context", nil, "commit('add', item);"},
+		{"return context.state.items;", "context", nil, "return state.items;"},
+		{"ctx.commit('add'); other.commit('add');", "ctx", nil, "commit('add'); other.commit('add');"},
+		{"save('add', item);", "", map[string]string{"save": "commit"}, "commit('add', item);"},
+		{"this.save(item);", "", map[string]string{"save": "commit"}, "this.save(item);"},
+	}
+
+	for _, test := range tests {
+		if line := replaceContextUses(test.line, test.name, test.aliases); line != test.expected {
+			t.Errorf("replaceContextUses(%q) = %q, expected %q", test.line, line, test.expected)
+		}
+	}
+}
+
+func TestMigrateActionContextParams(t *testing.T) {
+	var migrated = migratedModule(t, map[string]string{
+		"actions.js": "export default {\n  add(context, item) {\n    context.commit('add', item);\n  },\n  load({ commit: save, state }, item) {\n    save('add', item);\n    return state.items;\n  },\n};\n",
+	}, Config{})
+
+	assertContains(t, "actions.js", migrated["actions.js"],
+		"  add(item) {\n    this.add(item);",
+		"  load(item) {\n    this.add(item);\n    return this.items;",
+	)
+}
@@ -3,41 +3,54 @@ package parser
 import (
 	"bufio"
 	"fmt"
-	"log"
 	"os"
 	"regexp"
 	"slices"
+	"strings"
 )
 
 var getterPattern = map[string]*regexp.Regexp{
-	string("function"):           regexp.MustCompile(`\b(\w+).*\((state)(,\s.*)\)`),
-	string("function_lines_end"): regexp.MustCompile(`\s{2}\},$`),
-	string("getter_call"):        regexp.MustCompile(`getters\.(\w*)`),
-	string("root_state_call"):    regexp.MustCompile(`rootState\.(\w*)\.(\w*)`),
-	string("root_getter_call"):   regexp.MustCompile(`rootGetters\[("|')((\w+)\/(\w+))("|')\]`),
-	string("not_used_params"):    regexp.MustCompile(`(\w+)\((_(\w+)?)(,.*)\) \{`),
+	string("function"):           regexp.MustCompile(`^(\s{2})(\w+)\(([^)]*)\)(\:\s.+?)?\s\{$`),
+	string("function_lines_end"): regexp.MustCompile(`^\s{2}\},?$`),
+	string("getter_call"):        regexp.MustCompile(`\bgetters\.(\w*)`),
+	string("getter_key_call"):    regexp.MustCompile(`\bgetters\[("|')(\w+)("|')\]`),
 	string("import_store"):       regexp.MustCompile(`~/store/`),
+	string("this"):               regexp.MustCompile(`\bthis\b`),
+	string("state_prop"):         regexp.MustCompile(`(^|[^\w.$])state\.`),
+	string("state_spread"):       regexp.MustCompile(`\.\.\.state\b`),
+	string("return"):             regexp.MustCompile(`^\s*return\s(.+?);?$`),
+	string("boolean_expr"):       regexp.MustCompile(`^(true|false|!.+|.+(===|!==|==|!=|<=|>=|\s<\s|\s>\s).+|.+\.(includes|some|every|has|startsWith|endsWith)\(\))$`),
+	string("logical_op"):         regexp.MustCompile(`\s*(&&|\|\|)\s*`),
+	string("conditional_expr"):   regexp.MustCompile(`\?($|[^.])|:|=>`),
+	string("number_expr"):        regexp.MustCompile(`(\.(length|size)$|\s[-*/%]\s|^-?\d+(\.\d+)?$|^(Math|Number)\.)`),
+	string("string_expr"):        regexp.MustCompile("^(['\"`].*['\"`]|String\\(.*\\))$|\\.(join|toString|trim|toUpperCase|toLowerCase)\\(.*\\)$"),
 }
 
-func parseGetters(filesMap map[string]*os.File) []string {
+// vuex getters params, by position
+var getterParams = []string{"state", "getters", "rootState", "rootGetters"}
+
+func (mg *migration) parseGetters(filesMap map[string]*os.File) ([]string, error) {
 	file, ok := filesMap["getters"]
 	if !ok {
-		return []string{}
+		return []string{}, nil
 	}
 
-	if Verbose {
-		fmt.Printf("parsing: %s\n", file.Name())
-	}
-	scanner := bufio.NewScanner(file)
+	mg.verbosef("parsing: %s", file.Name())
+	scanner := bufio.NewScanner(mg.normalizeFunctions(file))
 
 	var lines []string
 	var functionStarted = false
+	var functionHeader []string
 	var functionLines = []string{}
-	var importedStores = []string{}
-	var intantiatedStores = []string{}
+	var paramAliases = map[string]string{}
+	var functionLineNumber = 0
+	var lineNumber = 0
+	var stores = crossStores{mg: mg}
+	var currentPath = mg.modulePathOf(file.Name())
 
 	for scanner.Scan() {
 		line := scanner.Text()
+		lineNumber++
 
 		if getterPattern["import_store"].FindStringSubmatch(line) != nil {
 			line = getterPattern["import_store"].ReplaceAllString(line, "~/stores/")
@@ -46,104 +59,242 @@ func parseGetters(filesMap map[string]*os.File) []string {
 			continue
 		}
 
-		if match := getterPattern["function"].FindStringSubmatch(line); match != nil {
-			line = getterPattern["function"].ReplaceAllString(line, "$1($2)")
+		if match := getterPattern["function"].FindStringSubmatch(line); match != nil && !functionStarted {
+			functionHeader = match
+			functionLineNumber = lineNumber
+			paramAliases = getterParamAliases(match[3])
 
 			// allows to check if a store has been declared
-			intantiatedStores = []string{}
+			stores.reset()
 			// allows to add lines at the beginning of the function
 			functionStarted = true
 
-			lines = append(lines, line)
 			continue
 		}
 
+		if functionStarted {
+			line = replaceContextUses(line, "", paramAliases)
+		}
+
+		if functionStarted {
+			// getters of modules without namespace
+			var defLines []string
+			line, defLines = stores.replaceGlobalGetters(line, currentPath)
+
+			functionLines = append(defLines, functionLines...)
+		}
+
 		if match := getterPattern["getter_call"].FindStringSubmatch(line); match != nil {
 			line = getterPattern["getter_call"].ReplaceAllString(line, "this.$1")
 		}
 
-		if match := getterPattern["root_state_call"].FindStringSubmatch(line); match != nil {
-			// get store name and function name to create instance
-			storeName := fmt.Sprintf("%s%sStore", match[1], capitalizeByteSlice(match[2]))
-			storeFn := fmt.Sprintf("use%s", capitalizeByteSlice(storeName))
-
-			// replace original line
-			var lineReplace = fmt.Sprintf("%s.$$state", storeName)
+		if match := getterPattern["getter_key_call"].FindStringSubmatch(line); match != nil {
+			// method-style getters are called the same way, `this.byId(id)`
+			line = getterPattern["getter_key_call"].ReplaceAllString(line, "this.$2")
+		}
 
-			line = getterPattern["root_state_call"].ReplaceAllString(line, lineReplace)
+		if functionStarted {
+			// get instances of root stores at the beginning of the function
+			var defLines []string
+			line, defLines = stores.replaceRootRefs(line)
 
-			if !slices.Contains(intantiatedStores, storeName) {
-				// get instance of root store
-				defLine := fmt.Sprintf("\t\tconst %s = %s()", storeName, storeFn)
+			functionLines = append(defLines, functionLines...)
+		}
 
-				functionLines = append([]string{defLine}, functionLines...)
+		if match := getterPattern["function_lines_end"].FindStringSubmatch(line); match != nil && functionStarted {
+			functionStarted = false
 
-				intantiatedStores = append(intantiatedStores, storeName)
-			}
+			getterLines := mg.translateGetter(functionHeader, functionLines, file.Name(), functionLineNumber)
+			lines = append(lines, getterLines...)
+			functionLines = []string{}
 
-			if !slices.Contains(importedStores, storeName) {
-				// create import statement of store
-				storeFilename := fmt.Sprintf("%s-%s", match[1], match[2])
-				importLine := fmt.Sprintf("import { %s } from '%s'", storeFn, fmt.Sprint("~/stores/", storeFilename))
+			continue
+		}
 
-				// add import statement to first line
-				lines = append([]string{importLine}, lines...)
-				importedStores = append(importedStores, storeName)
-			}
+		if functionStarted {
+			functionLines = append(functionLines, line)
+			// if adding function content go to next line
+			continue
 		}
 
-		if match := getterPattern["root_getter_call"].FindStringSubmatch(line); match != nil {
+		lines = append(lines, line)
+	}
 
-			storeFn := fmt.Sprintf("use%sStore", capitalizeByteSlice(match[3]))
-			storeName := fmt.Sprint(match[3], "Store")
-			fnName := match[4]
+	// add import statements of stores, merged with the existing ones
+	appendImports(&lines, &stores.imports)
 
-			line = getterPattern["root_getter_call"].ReplaceAllString(line, fmt.Sprintf("%s.%s", storeName, fnName))
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
 
-			if !slices.Contains(intantiatedStores, storeName) {
-				// get instance of root store
-				defLine := fmt.Sprintf("\t\tconst %s = %s()", storeName, storeFn)
+	return lines, nil
+}
 
-				functionLines = append([]string{defLine}, functionLines...)
+// getterParamAliases maps the names given to the getter params to the vuex
+// names, e.g. `(s, g)` are state and getters
+func getterParamAliases(params string) map[string]string {
+	var aliases = map[string]string{}
 
-				intantiatedStores = append(intantiatedStores, storeName)
-			}
+	for index, param := range strings.Split(params, ",") {
+		name, _, _ := strings.Cut(param, ":")
+		name, _, _ = strings.Cut(name, "=")
+		name = strings.TrimSpace(name)
 
-			if !slices.Contains(importedStores, storeName) {
-				// create import statement of store
-				importLine := fmt.Sprintf("import { %s } from '%s'", storeFn, fmt.Sprint("~/stores/", storeName))
+		if index >= len(getterParams) || name == "" || strings.HasPrefix(name, "_") {
+			continue
+		}
 
-				// add import statement to first line
-				lines = append([]string{importLine}, lines...)
-				importedStores = append(importedStores, storeName)
-			}
+		if name != getterParams[index] {
+			aliases[name] = getterParams[index]
 		}
+	}
+
+	return aliases
+}
+
+// translateGetter returns the getter as an arrow function receiving the state
+// when it is possible, otherwise as a regular function using `this`, which
+// requires the return type to be annotated in typescript
+func (mg *migration) translateGetter(header []string, body []string, filename string, lineNumber int) []string {
+	var indent, name, returnType = header[1], header[2], header[4]
+	var usesThis = slices.ContainsFunc(body, func(line string) bool {
+		return getterPattern["this"].FindStringSubmatch(line) != nil
+	})
+	var lines = []string{}
+
+	if usesThis {
+		for _, line := range body {
+			line = getterPattern["state_prop"].ReplaceAllString(line, "${1}this.")
+			line = getterPattern["state_spread"].ReplaceAllString(line, "...this.$$state")
+			line = wordPattern("state").ReplaceAllString(line, "${1}this.$$state")
 
-		if match := getterPattern["not_used_params"].FindStringSubmatch(line); match != nil {
-			line = getterPattern["not_used_params"].ReplaceAllString(line, "$1() {")
+			lines = append(lines, line)
 		}
 
-		if match := getterPattern["function_lines_end"].FindStringSubmatch(line); match != nil {
-			functionStarted = false
+		if returnType == "" && strings.HasSuffix(filename, ".ts") {
+			returnType = fmt.Sprintf(": %s", inferReturnType(lines))
 		}
 
-		if functionStarted {
-			functionLines = append(functionLines, line)
-			// if adding function content go to next line
+		if returnType == ": any" {
+			mg.addDiagnostic(filename, lineNumber, "return type of getter '%s' could not be inferred, using any", name)
+		}
+
+		lines = append([]string{fmt.Sprintf("%s%s()%s {", indent, name, returnType)}, lines...)
+
+		return append(lines, fmt.Sprintf("%s},", indent))
+	}
+
+	var params = ""
+	if slices.ContainsFunc(body, func(line string) bool {
+		return wordPattern("state").FindStringSubmatch(line) != nil
+	}) {
+		params = "state"
+	}
+
+	// single return statements are written as concise body
+	if len(body) == 1 {
+		if match := getterPattern["return"].FindStringSubmatch(body[0]); match != nil {
+			var expr = match[1]
+			if strings.HasPrefix(expr, "{") {
+				expr = fmt.Sprintf("(%s)", expr)
+			}
+
+			return []string{fmt.Sprintf("%s%s: (%s)%s => %s,", indent, name, params, returnType, expr)}
+		}
+	}
+
+	lines = append(lines, fmt.Sprintf("%s%s: (%s)%s => {", indent, name, params, returnType))
+	lines = append(lines, body...)
+
+	return append(lines, fmt.Sprintf("%s},", indent))
+}
+
+// inferReturnType guesses the type of the value returned by a getter, pinia
+// needs it to type getters using `this`. Only the operators applied to the
+// whole value are checked, conditionals are not inferred
+func inferReturnType(body []string) string {
+	for index := len(body) - 1; index >= 0; index-- {
+		match := getterPattern["return"].FindStringSubmatch(body[index])
+		if match == nil {
 			continue
 		}
 
-		if len(functionLines) > 0 {
-			lines = append(lines, functionLines...)
-			functionLines = []string{}
+		var expr = topLevelExpr(strings.TrimSpace(match[1]))
+
+		switch {
+		case getterPattern["conditional_expr"].FindStringSubmatch(expr) != nil:
+		case isBooleanExpr(expr):
+			return "boolean"
+		case getterPattern["logical_op"].FindStringSubmatch(expr) != nil:
+			// `a || b` returns one of the operands
+		case getterPattern["number_expr"].FindStringSubmatch(expr) != nil:
+			return "number"
+		case getterPattern["string_expr"].FindStringSubmatch(expr) != nil:
+			return "string"
 		}
 
-		lines = append(lines, line)
+		break
 	}
 
-	if err := scanner.Err(); err != nil {
-		log.Fatal(err)
+	return "any"
+}
+
+// isBooleanExpr checks if every operand of the logical operators of the
+// expression is a comparison, a negation or a boolean literal
+func isBooleanExpr(expr string) bool {
+	for _, operand := range getterPattern["logical_op"].Split(expr, -1) {
+		if getterPattern["boolean_expr"].FindStringSubmatch(operand) == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// topLevelExpr removes the content of the brackets and the string literals
+// of an expression, e.g. `a.filter((b) => b > 0).length` becomes
+// `a.filter().length`
+func topLevelExpr(expr string) string {
+	var result = []byte{}
+	var depth = 0
+	var quote byte
+
+	for index := 0; index < len(expr); index++ {
+		var char = expr[index]
+
+		if quote != 0 {
+			if char == '\\' {
+				index++
+			} else if char == quote {
+				quote = 0
+
+				if depth == 0 {
+					result = append(result, char)
+				}
+			}
+
+			continue
+		}
+
+		switch char {
+		case '"', '\'', '`':
+			quote = char
+		case '(', '[', '{':
+			depth++
+
+			if depth == 1 {
+				result = append(result, char)
+			}
+
+			continue
+		case ')', ']', '}':
+			depth--
+		}
+
+		if depth == 0 {
+			result = append(result, char)
+		}
 	}
 
-	return lines
+	return string(result)
 }
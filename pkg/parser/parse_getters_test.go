@@ -0,0 +1,66 @@
+package parser
+
+import "testing"
+
+func TestInferReturnType(t *testing.T) {
+	var tests = []struct {
+		body     string
+		expected string
+	}{
+		{"    return this.count > 0;", "boolean"},
+		{"    return !this.user;", "boolean"},
+		{"    return this.items.includes(id);", "boolean"},
+		{"    return this.a === 1 && this.b !== 2;", "boolean"},
+		{"    return true;", "boolean"},
+		{"    return this.count > 0 ? \"full\" : \"empty\";", "any"},
+		{"    return this.user && this.user.name;", "any"},
+		{"    return this.name || 'guest';", "any"},
+		{"    return this.label ?? 'none';", "any"},
+		{"    return this.items.filter((item) => item.count > 0);", "any"},
+		{"    return this.items.filter((item) => item.done).length;", "number"},
+		{"    return this.user?.age;", "any"},
+		{"    return this.total * 2;", "number"},
+		{"    return 'a > b';", "string"},
+		{"    return this.tags.join(', ');", "string"},
+		{"    return this.user;", "any"},
+	}
+
+	for _, test := range tests {
+		if result := inferReturnType([]string{test.body}); result != test.expected {
+			t.Errorf("inferReturnType(%q) = %s, expected %s", test.body, result, test.expected)
+		}
+	}
+}
+
+func TestTopLevelExpr(t *testing.T) {
+	var tests = []struct {
+		expr     string
+		expected string
+	}{
+		{"a.filter((b) => b > 0).length", "a.filter().length"},
+		{"'a ? b' + c", "'' + c"},
+		{"`${a}` + 'it\\'s'", "`` + ''"},
+		{"{ a: 1 }", "{}"},
+	}
+
+	for _, test := range tests {
+		if result := topLevelExpr(test.expr); result != test.expected {
+			t.Errorf("topLevelExpr(%q) = %q, expected %q", test.expr, result, test.expected)
+		}
+	}
+}
+
+func TestMigrateMethodGetters(t *testing.T) {
+	var migrated = migratedModule(t, map[string]string{
+		"getters.js": "export default {\n  byId: (state) => (id) => state.items.find((item) => item.id === id),\n  first(state, getters) {\n    return getters['byId'](1);\n  },\n  name: (state, getters) => (id) => getters.byId(id).name,\n};\n",
+		"actions.js": "export default {\n  show({ getters }, id) {\n    return getters['byId'](id);\n  },\n};\n",
+	}, Config{})
+
+	assertContains(t, "getters.js", migrated["getters.js"],
+		"  byId: (state) => (id) => state.items.find((item) => item.id === id),",
+		"  first() {\n    return this.byId(1);",
+		"  name() {\n    return (id) => this.byId(id).name;",
+	)
+
+	assertContains(t, "actions.js", migrated["actions.js"], "  show(id) {\n    return this.byId(id);")
+}
@@ -3,7 +3,6 @@ package parser
 import (
 	"bufio"
 	"fmt"
-	"log"
 	"os"
 	"regexp"
 	"strings"
@@ -19,18 +18,20 @@ var mutPattern = map[string]*regexp.Regexp{
 	string("import_store"):         regexp.MustCompile(`~/store/`),
 	string("import_multiline"):     regexp.MustCompile(`^import\s\{$`),
 	string("import_multiline_end"): regexp.MustCompile(`^\}\sfrom\s.*(;|)$`),
+	string("reactivity_call"):      regexp.MustCompile(`\b(Vue\.|this\._vm\.\$)(set|delete)\(`),
+	string("vue_import"):           regexp.MustCompile(`^import\s+Vue(\s*,\s*(\{.*\}))?\s+from\s+('|")vue('|")(;?)$`),
+	string("string_key"):           regexp.MustCompile(`^('|")[^'"]*('|")$`),
+	string("index_key"):            regexp.MustCompile(`^\d+$`),
 }
 
-func parseMutations(filesMap map[string]*os.File) ([]string, []string) {
+func (mg *migration) parseMutations(filesMap map[string]*os.File) ([]string, []string, error) {
 	file, ok := filesMap["mutations"]
 	if !ok {
-		return []string{}, []string{}
+		return []string{}, []string{}, nil
 	}
 
-	if Verbose {
-		fmt.Printf("parsing: %s\n", file.Name())
-	}
-	scanner := bufio.NewScanner(file)
+	mg.verbosef("parsing: %s", file.Name())
+	scanner := bufio.NewScanner(mg.normalizeFunctions(file))
 
 	var lines []string
 	var importLines []string
@@ -82,6 +83,10 @@ func parseMutations(filesMap map[string]*os.File) ([]string, []string) {
 			} else if mutPattern["state_prop_2"].FindStringSubmatch(line) != nil {
 				line = mutPattern["state_prop_2"].ReplaceAllString(line, "this$2")
 			}
+
+			if mutPattern["reactivity_call"].FindStringSubmatch(line) != nil {
+				line = replaceReactivityCalls(line)
+			}
 		}
 
 		if isFn && index >= 0 {
@@ -98,8 +103,78 @@ func parseMutations(filesMap map[string]*os.File) ([]string, []string) {
 	}
 
 	if err := scanner.Err(); err != nil {
-		log.Fatal(err)
+		return nil, nil, err
+	}
+
+	return lines, importLines, nil
+}
+
+// replaceReactivityCalls rewrites `Vue.set` and `Vue.delete` calls, not
+// needed with the reactivity system used by pinia, into plain assignments
+// and deletions. Deletions by an index are written as an array splice, and
+// deletions by a variable key check the target at runtime
+func replaceReactivityCalls(line string) string {
+	var offset = 0
+
+	for {
+		loc := mutPattern["reactivity_call"].FindStringSubmatchIndex(line[offset:])
+		if loc == nil {
+			break
+		}
+
+		var start = offset + loc[0]
+		var method = line[offset+loc[4] : offset+loc[5]]
+
+		args, end := splitCallArgs(line, offset+loc[1])
+		if end < 0 {
+			break
+		}
+
+		var expr string
+
+		if method == "set" && len(args) == 3 {
+			expr = fmt.Sprintf("%s[%s] = %s", args[0], args[1], args[2])
+		} else if method == "delete" && len(args) == 2 && mutPattern["string_key"].FindStringSubmatch(args[1]) != nil {
+			expr = fmt.Sprintf("delete %s[%s]", args[0], args[1])
+		} else if method == "delete" && len(args) == 2 && mutPattern["index_key"].FindStringSubmatch(args[1]) != nil {
+			// the delete operator leaves a hole in arrays
+			expr = fmt.Sprintf("%s.splice(%s, 1)", args[0], args[1])
+		} else if method == "delete" && len(args) == 2 {
+			expr = fmt.Sprintf("Array.isArray(%[1]s) ? %[1]s.splice(%[2]s, 1) : delete %[1]s[%[2]s]", args[0], args[1])
+		} else {
+			offset = end
+			continue
+		}
+
+		line = line[:start] + expr + line[end:]
+		offset = start + len(expr)
+	}
+
+	return line
+}
+
+// removeVueImport removes the default import of vue when it is not used
+// anymore, keeping the named imports
+func removeVueImport(lines *[]string) {
+	var importIndex = -1
+
+	for index, line := range *lines {
+		if mutPattern["vue_import"].FindStringSubmatch(line) != nil {
+			importIndex = index
+		} else if wordPattern("Vue").FindStringSubmatch(line) != nil {
+			return
+		}
+	}
+
+	if importIndex < 0 {
+		return
+	}
+
+	match := mutPattern["vue_import"].FindStringSubmatch((*lines)[importIndex])
+	if match[2] != "" {
+		(*lines)[importIndex] = fmt.Sprintf("import %s from %svue%s%s", match[2], match[3], match[4], match[5])
+		return
 	}
 
-	return lines, importLines
+	*lines = append((*lines)[:importIndex], (*lines)[importIndex+1:]...)
 }
@@ -0,0 +1,46 @@
+package parser
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestReplaceReactivityCalls(t *testing.T) {
+	var tests = []struct {
+		line     string
+		expected string
+	}{
+		{"    Vue.set(this.items, index, item);", "    this.items[index] = item;"},
+		{"    Vue.set(this.user, 'name', name);", "    this.user['name'] = name;"},
+		{"    Vue.delete(this.user, 'name');", "    delete this.user['name'];"},
+		{"    Vue.delete(this.items, 0);", "    this.items.splice(0, 1);"},
+		{"    Vue.delete(this.items, i);", "    Array.isArray(this.items) ? this.items.splice(i, 1) : delete this.items[i];"},
+		{"    this._vm.$delete(this.byId, item.id);", "    Array.isArray(this.byId) ? this.byId.splice(item.id, 1) : delete this.byId[item.id];"},
+		{"    Vue.set(this.items, index);", "    Vue.set(this.items, index);"},
+	}
+
+	for _, test := range tests {
+		if result := replaceReactivityCalls(test.line); result != test.expected {
+			t.Errorf("replaceReactivityCalls(%q) = %q, expected %q", test.line, result, test.expected)
+		}
+	}
+}
+
+func TestRemoveVueImport(t *testing.T) {
+	var tests = []struct {
+		lines    []string
+		expected []string
+	}{
+		{[]string{"import Vue from 'vue';", "", "this.items[0] = 1;"}, []string{"", "this.items[0] = 1;"}},
+		{[]string{"import Vue, { ref } from 'vue';", "ref(0);"}, []string{"import { ref } from 'vue';", "ref(0);"}},
+		{[]string{"import Vue from 'vue';", "Vue.nextTick();"}, []string{"import Vue from 'vue';", "Vue.nextTick();"}},
+	}
+
+	for _, test := range tests {
+		var lines = slices.Clone(test.lines)
+
+		if removeVueImport(&lines); !slices.Equal(lines, test.expected) {
+			t.Errorf("removeVueImport(%q) = %q, expected %q", test.lines, lines, test.expected)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+var persistPattern = map[string]*regexp.Regexp{
+	string("paths"):   regexp.MustCompile(`\bpaths:\s*\[([^\]]*)\]`),
+	string("storage"): regexp.MustCompile(`\bstorage:\s*([^,}\s]+)`),
+	string("options"): regexp.MustCompile(`\b(key|reducer|filter|getState|setState|subscriber|assertStorage|arrayMerger|rehydrated|fetchBeforeUse|overwrite):`),
+	string("string"):  regexp.MustCompile(`('|")([^'"]+)('|")`),
+}
+
+const PERSISTED_STATE_PACKAGE = "vuex-persistedstate"
+
+// isPersistedState checks if the plugin is created by vuex-persistedstate,
+// directly or through a constant
+func isPersistedState(entry string, imports map[string]string, lines []string) bool {
+	for name, path := range imports {
+		if path != PERSISTED_STATE_PACKAGE {
+			continue
+		}
+
+		if strings.HasPrefix(entry, fmt.Sprint(name, "(")) {
+			return true
+		}
+
+		var constPattern = regexp.MustCompile(fmt.Sprintf(`^(export\s+)?const\s+%s\s*=\s*%s\(`, regexp.QuoteMeta(entry), regexp.QuoteMeta(name)))
+
+		if slices.ContainsFunc(lines, func(line string) bool { return constPattern.FindStringSubmatch(line) != nil }) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findPersistedState translates the paths of the vuex-persistedstate plugin
+// of the root store into the persist option of each store
+func (mg *migration) findPersistedState() map[string]string {
+	var persist = map[string]string{}
+
+	rootPath, lines, err := readRootStore(mg.root)
+	if err != nil || rootPath == "" {
+		return persist
+	}
+
+	var content = strings.Join(lines, "\n")
+	var options = ""
+	var found = false
+
+	for _, line := range lines {
+		match := pluginPattern["import"].FindStringSubmatch(line)
+		if match == nil || match[3] != PERSISTED_STATE_PACKAGE {
+			continue
+		}
+
+		loc := regexp.MustCompile(fmt.Sprintf(`\b%s\(`, regexp.QuoteMeta(match[1]))).FindStringIndex(content)
+		if loc == nil {
+			continue
+		}
+
+		args, _ := splitCallArgs(content, loc[1])
+		if len(args) > 0 {
+			options = args[0]
+		}
+
+		found = true
+	}
+
+	if !found {
+		return persist
+	}
+
+	var parts = []string{}
+
+	if match := persistPattern["storage"].FindStringSubmatch(options); match != nil {
+		parts = append(parts, fmt.Sprintf("storage: %s", match[1]))
+	}
+
+	for _, match := range persistPattern["options"].FindAllStringSubmatch(options, -1) {
+		mg.addDiagnostic(rootPath, 0, "persisted state option '%s' is not migrated, check the pinia-plugin-persistedstate options", match[1])
+	}
+
+	var option = "true"
+	if len(parts) > 0 {
+		option = fmt.Sprintf("{ %s }", strings.Join(parts, ", "))
+	}
+
+	match := persistPattern["paths"].FindStringSubmatch(options)
+	if match == nil {
+		// the whole state is persisted
+		for modulePath := range mg.modules {
+			persist[modulePath] = option
+		}
+
+		return persist
+	}
+
+	var picks = map[string][]string{}
+	var whole = []string{}
+
+	for _, path := range persistPattern["string"].FindAllStringSubmatch(match[1], -1) {
+		modulePath, pick := mg.persistedModule(path[2])
+		if modulePath == "" {
+			mg.addDiagnostic(rootPath, 0, "persisted path '%s' does not belong to a module", path[2])
+			continue
+		}
+
+		if pick == "" {
+			whole = append(whole, modulePath)
+		} else {
+			picks[modulePath] = append(picks[modulePath], fmt.Sprintf("'%s'", pick))
+		}
+	}
+
+	for _, modulePath := range whole {
+		persist[modulePath] = option
+	}
+
+	for modulePath, paths := range picks {
+		if slices.Contains(whole, modulePath) {
+			continue
+		}
+
+		sort.Strings(paths)
+
+		persist[modulePath] = fmt.Sprintf("{ %s }", strings.Join(append([]string{fmt.Sprintf("pick: [%s]", strings.Join(paths, ", "))}, parts...), ", "))
+	}
+
+	return persist
+}
+
+// persistedModule returns the module of a persisted state path, and the
+// path of the state property inside the module
+func (mg *migration) persistedModule(path string) (string, string) {
+	var segments = strings.Split(path, ".")
+
+	for index := len(segments); index > 0; index-- {
+		var modulePath = strings.Join(segments[:index], "/")
+
+		if _, ok := mg.modules[modulePath]; ok {
+			return modulePath, strings.Join(segments[index:], ".")
+		}
+	}
+
+	return "", ""
+}
@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"testing"
+)
+
+// persistedStore writes a root store registering vuex-persistedstate with
+// the given options
+func persistedStore(t *testing.T, options string) *migration {
+	var root = t.TempDir()
+	var index = []string{
+		"import Vuex from 'vuex';",
+		"import createPersistedState from 'vuex-persistedstate';",
+		"",
+		"export default new Vuex.Store({",
+		fmt.Sprintf("  plugins: [createPersistedState(%s)],", options),
+		"});",
+		"",
+	}
+
+	writeModule(t, root, ".", map[string]string{"index.js": strings.Join(index, "\n")})
+	writeModule(t, root, "cart", cartModule)
+	writeModule(t, root, "cart/items", cartModule)
+	writeModule(t, root, "user", cartModule)
+
+	var mg = newMigration(root, Config{})
+	mg.modules = mg.discoverModules()
+
+	return mg
+}
+
+func TestFindPersistedState(t *testing.T) {
+	var tests = []struct {
+		options  string
+		expected map[string]string
+	}{
+		{"", map[string]string{"cart": "true", "cart/items": "true", "user": "true"}},
+		{"{ storage: window.sessionStorage }", map[string]string{"cart": "{ storage: window.sessionStorage }", "cart/items": "{ storage: window.sessionStorage }", "user": "{ storage: window.sessionStorage }"}},
+		{"{ paths: ['user', 'cart.items.list', 'cart.total', 'cart.coupon'] }", map[string]string{"user": "true", "cart/items": "{ pick: ['list'] }", "cart": "{ pick: ['coupon', 'total'] }"}},
+		{"{ paths: ['user', 'unknown.value'] }", map[string]string{"user": "true"}},
+	}
+
+	for _, test := range tests {
+		if persist := persistedStore(t, test.options).findPersistedState(); !maps.Equal(persist, test.expected) {
+			t.Errorf("findPersistedState(%s) = %v", test.options, persist)
+		}
+	}
+}
+
+func TestFindPersistedStateReportsOptions(t *testing.T) {
+	var mg = persistedStore(t, "{ key: 'app', paths: ['unknown'] }")
+	mg.findPersistedState()
+
+	var messages = []string{}
+	for _, diagnostic := range mg.diagnostics {
+		messages = append(messages, diagnostic.Message)
+	}
+
+	for _, expected := range []string{"persisted state option 'key' is not migrated", "persisted path 'unknown' does not belong to a module"} {
+		if !strings.Contains(strings.Join(messages, "\n"), expected) {
+			t.Errorf("diagnostics %q do not report %q", messages, expected)
+		}
+	}
+}
+
+func TestIsPersistedState(t *testing.T) {
+	var imports = map[string]string{"createPersistedState": PERSISTED_STATE_PACKAGE, "logger": "vuex/dist/logger"}
+	var lines = []string{"const persisted = createPersistedState({ paths: ['user'] });"}
+
+	for entry, expected := range map[string]bool{
+		"createPersistedState()": true,
+		"persisted":              true,
+		"logger()":               false,
+	} {
+		if isPersistedState(entry, imports, lines) != expected {
+			t.Errorf("isPersistedState(%s) != %t", entry, expected)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var pluginPattern = map[string]*regexp.Regexp{
+	string("root_store"):       regexp.MustCompile(`\bnew\s+Vuex\.Store\(|\bcreateStore\(`),
+	string("import"):           regexp.MustCompile(`^import\s+(\w+)\s+from\s+('|")(.+)('|");?$`),
+	string("plugins"):          regexp.MustCompile(`^\s*plugins:\s*\[`),
+	string("const_plugin"):     regexp.MustCompile(`^(export\s+)?const\s+(\w+)(\:\s[^=]+)?\s*=\s*\(?(\w+)(\:\s[^)]+)?\)?\s*=>\s*\{$`),
+	string("function_plugin"):  regexp.MustCompile(`^(export\s+)?function\s+(\w+)\s*\((\w+)(\:\s[^)]+)?\)\s*\{$`),
+	string("default_plugin"):   regexp.MustCompile(`^export\s+default\s+(function\s*\w*\s*\((\w+)(\:\s[^)]+)?\)|\(?(\w+)(\:\s[^)]+)?\)?\s*=>)\s*\{$`),
+	string("block_end"):        regexp.MustCompile(`^\}\)?;?$`),
+	string("subscribe"):        regexp.MustCompile(`^(\s*)(\w+)\.subscribe\(\(?(\w+)(,\s*(\w+))?\)?\s*=>\s*\{$`),
+	string("subscribe_action"): regexp.MustCompile(`^(\s*)(\w+)\.subscribeAction\(\(?(\w+)(,\s*(\w+))?\)?\s*=>\s*\{$`),
+	string("unsupported"):      regexp.MustCompile(`\b(\w+)\.(watch|subscribeAction|commit|dispatch|getters|replaceState|registerModule|unregisterModule|hotUpdate)\b`),
+}
+
+// vuex plugin found in the root store
+type storePlugin struct {
+	name  string
+	param string
+	body  []string
+	// file defining the plugin when it is not the root store
+	source string
+}
+
+const PLUGINS_FILENAME = "pinia-plugins"
+
+// migratePlugins translates the plugins registered in the root store into
+// pinia plugins and replaces the root store with the pinia bootstrap, the
+// plugins not migrated are kept as comments. The members of the root store
+// were moved to the root module by splitRootStore
+func (mg *migration) migratePlugins() error {
+	rootPath, lines, err := readRootStore(mg.root)
+	if err != nil || rootPath == "" {
+		return err
+	}
+
+	var imports = map[string]string{}
+
+	for _, line := range lines {
+		if match := pluginPattern["import"].FindStringSubmatch(line); match != nil {
+			imports[match[1]] = match[3]
+		}
+	}
+
+	var ext = filepath.Ext(rootPath)
+	if mg.config.Lang == LANG_TS {
+		ext = ".ts"
+	}
+
+	var plugins = []storePlugin{}
+	var registered = []string{}
+
+	var bootstrapImports = []string{}
+
+	for _, entry := range findPlugins(lines) {
+		if isPersistedState(entry, imports, lines) {
+			bootstrapImports = append(bootstrapImports, "import piniaPluginPersistedstate from 'pinia-plugin-persistedstate';")
+			registered = append(registered, "pinia.use(piniaPluginPersistedstate);")
+			continue
+		}
+
+		if dynamicPattern["identifier"].FindStringSubmatch(entry) == nil {
+			mg.addDiagnostic(rootPath, 0, "plugin %s has no pinia equivalent, it must be migrated by hand", entry)
+			registered = append(registered, fmt.Sprintf("// TODO: vuex plugin, migrate it by hand: %s", entry))
+			continue
+		}
+
+		plugin, ok := findPlugin(lines, entry)
+		if importPath, imported := imports[entry]; !ok && imported && strings.HasPrefix(importPath, ".") {
+			plugin, ok = findImportedPlugin(filepath.Join(mg.root, importPath), entry)
+		}
+
+		if !ok {
+			mg.addDiagnostic(rootPath, 0, "plugin %s definition not found, it must be migrated by hand", entry)
+			registered = append(registered, fmt.Sprintf("// TODO: vuex plugin, migrate it by hand: %s", entry))
+			continue
+		}
+
+		if plugin.source != "" {
+			mg.addDiagnostic(plugin.source, 0, "plugin %s migrated to %s, the file can be removed", entry, fmt.Sprint(PLUGINS_FILENAME, ext))
+		}
+
+		plugins = append(plugins, plugin)
+		registered = append(registered, fmt.Sprintf("pinia.use(%s);", entry))
+	}
+
+	if len(plugins) > 0 {
+		var names = []string{}
+		for _, plugin := range plugins {
+			names = append(names, plugin.name)
+		}
+
+		bootstrapImports = append(bootstrapImports, fmt.Sprintf("import { %s } from './%s';", strings.Join(names, ", "), PLUGINS_FILENAME))
+
+		pluginsFile := mg.writePlugins(plugins, ext == ".ts")
+		if err := os.WriteFile(filepath.Join(mg.root, fmt.Sprint(PLUGINS_FILENAME, ext)), []byte(pluginsFile), 0644); err != nil {
+			return err
+		}
+	}
+
+	var values = map[string]string{
+		"imports": strings.Join(bootstrapImports, "\n"),
+		"plugins": strings.Join(registered, "\n"),
+	}
+
+	mg.addDiagnostic(rootPath, 0, "vuex root store replaced by the pinia bootstrap")
+
+	var bootstrapPath = fmt.Sprint(strings.TrimSuffix(rootPath, filepath.Ext(rootPath)), ext)
+	if bootstrapPath != rootPath {
+		if err := os.Remove(rootPath); err != nil {
+			return err
+		}
+	}
+
+	return createTemplate(PINIA_TEMPLATE, bootstrapPath, values)
+}
+
+// readRootStore returns the path and the lines of the file creating the
+// vuex store, the path is empty when there is no root store
+func readRootStore(root string) (string, []string, error) {
+	for _, ext := range []string{".ts", ".js"} {
+		var path = filepath.Join(root, fmt.Sprint("index", ext))
+		if !fileExists(path) {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if pluginPattern["root_store"].FindStringSubmatch(string(content)) == nil {
+			return "", nil, nil
+		}
+
+		return path, strings.Split(string(content), "\n"), nil
+	}
+
+	return "", nil, nil
+}
+
+// findPlugins returns the entries of the plugins option of the root store
+func findPlugins(lines []string) []string {
+	for index, line := range lines {
+		if pluginPattern["plugins"].FindStringSubmatch(line) == nil {
+			continue
+		}
+
+		// plugins can be declared in multiple lines
+		var content = strings.Join(lines[index:], "\n")
+		args, _ := splitCallArgs(content, strings.Index(content, "[")+1)
+
+		return args
+	}
+
+	return []string{}
+}
+
+// findPlugin returns the plugin function declared in the lines
+func findPlugin(lines []string, name string) (storePlugin, bool) {
+	for index, line := range lines {
+		var param string
+
+		if match := pluginPattern["const_plugin"].FindStringSubmatch(line); match != nil && match[2] == name {
+			param = match[4]
+		} else if match := pluginPattern["function_plugin"].FindStringSubmatch(line); match != nil && match[2] == name {
+			param = match[3]
+		} else if match := pluginPattern["default_plugin"].FindStringSubmatch(line); match != nil && name == "" {
+			param = match[2] + match[4]
+		} else {
+			continue
+		}
+
+		var body = []string{}
+
+		for index++; index < len(lines) && pluginPattern["block_end"].FindStringSubmatch(lines[index]) == nil; index++ {
+			body = append(body, lines[index])
+		}
+
+		return storePlugin{name: name, param: param, body: body}, true
+	}
+
+	return storePlugin{}, false
+}
+
+// findImportedPlugin returns the plugin exported by default by a file
+func findImportedPlugin(path string, name string) (storePlugin, bool) {
+	for _, filename := range []string{path, fmt.Sprint(path, ".ts"), fmt.Sprint(path, ".js"), filepath.Join(path, "index.ts"), filepath.Join(path, "index.js")} {
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			continue
+		}
+
+		plugin, ok := findPlugin(strings.Split(string(content), "\n"), "")
+		plugin.name = name
+		plugin.source = filename
+
+		return plugin, ok
+	}
+
+	return storePlugin{}, false
+}
+
+// writePlugins returns the content of the file with the pinia plugins
+func (mg *migration) writePlugins(plugins []storePlugin, typed bool) string {
+	var lines = []string{}
+	var param = "{ store }"
+
+	if typed {
+		lines = append(lines, "import type { PiniaPluginContext } from 'pinia';", "")
+		param = "{ store }: PiniaPluginContext"
+	}
+
+	for _, plugin := range plugins {
+		lines = append(lines, "// TODO: vuex plugins run once for the root store, pinia plugins run once for every store")
+		lines = append(lines, fmt.Sprintf("export function %s(%s) {", plugin.name, param))
+		lines = append(lines, mg.translatePluginBody(plugin.body, plugin.param)...)
+		lines = append(lines, "}", "")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// translatePluginBody rewrites vuex store subscriptions into pinia ones,
+// adding TODO comments where the behaviour differs
+func (mg *migration) translatePluginBody(body []string, storeParam string) []string {
+	var lines = []string{}
+
+	for index := 0; index < len(body); index++ {
+		line := body[index]
+
+		if match := pluginPattern["subscribe"].FindStringSubmatch(line); match != nil && match[2] == storeParam {
+			// mutations are actions in pinia, subscribers are called after them
+			callback, end := callbackBody(body, index)
+			index = end
+
+			lines = append(lines, fmt.Sprintf("%sstore.$onAction(({ name, args, after }) => {", match[1]))
+			lines = append(lines, fmt.Sprintf("%s  after(() => {", match[1]))
+			lines = append(lines, indentLines(mg.translateSubscriber(callback, match[3], match[5], match[1]+"  "), "  ")...)
+			lines = append(lines, fmt.Sprintf("%s  });", match[1]))
+			lines = append(lines, fmt.Sprintf("%s});", match[1]))
+
+			continue
+		}
+
+		if match := pluginPattern["subscribe_action"].FindStringSubmatch(line); match != nil && match[2] == storeParam {
+			callback, end := callbackBody(body, index)
+			index = end
+
+			lines = append(lines, fmt.Sprintf("%sstore.$onAction(({ name, args }) => {", match[1]))
+			lines = append(lines, mg.translateSubscriber(callback, match[3], match[5], match[1]+"  ")...)
+			lines = append(lines, fmt.Sprintf("%s});", match[1]))
+
+			continue
+		}
+
+		if match := pluginPattern["unsupported"].FindStringSubmatch(line); match != nil && match[1] == storeParam {
+			var indent = line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+
+			lines = append(lines, fmt.Sprintf("%s// TODO: store.%s has no pinia equivalent, use store.$subscribe or the store instances", indent, match[2]))
+		}
+
+		line = regexp.MustCompile(fmt.Sprintf(`\b%s\.state\b`, regexp.QuoteMeta(storeParam))).ReplaceAllString(line, "store.$$state")
+		line = wordPattern(storeParam).ReplaceAllString(line, "${1}store")
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// callbackBody returns the lines of the callback starting at the given
+// index and the index of its closing line
+func callbackBody(body []string, index int) ([]string, int) {
+	var balance = bracketBalance(body[index])
+	var callback = []string{}
+
+	for index++; index < len(body); index++ {
+		balance += bracketBalance(body[index])
+		if balance <= 0 {
+			break
+		}
+
+		callback = append(callback, body[index])
+	}
+
+	return callback, index
+}
+
+// translateSubscriber rewrites the mutation or action and the root state
+// received by a vuex subscriber
+func (mg *migration) translateSubscriber(callback []string, event string, state string, indent string) []string {
+	var typeCheck = regexp.MustCompile(fmt.Sprintf(`\b%s\.type\s*(===|==|!==|!=)\s*('|")([\w/]+)('|")`, regexp.QuoteMeta(event)))
+	var typeProp = regexp.MustCompile(fmt.Sprintf(`\b%s\.type\b`, regexp.QuoteMeta(event)))
+	var payloadProp = regexp.MustCompile(fmt.Sprintf(`\b%s\.payload\b`, regexp.QuoteMeta(event)))
+	var lines = []string{}
+
+	if state != "" && slices.ContainsFunc(callback, func(line string) bool { return wordPattern(state).FindStringSubmatch(line) != nil }) {
+		lines = append(lines, fmt.Sprintf("%s// TODO: the root state is not available, store.$state is the state of the current store", indent))
+	}
+
+	for _, line := range callback {
+		line = typeCheck.ReplaceAllStringFunc(line, func(check string) string {
+			match := typeCheck.FindStringSubmatch(check)
+
+			var condition = fmt.Sprintf("name === '%s'", match[3])
+			if namespace, name, ok := cutLast(match[3], "/"); ok {
+				// stores are defined with the name of the module directory
+				_, storeId, _ := cutLast(mg.resolveNamespace(namespace), "/")
+				condition = fmt.Sprintf("(store.$id === '%s' && name === '%s')", storeId, name)
+			}
+
+			if strings.HasPrefix(match[1], "!") {
+				return fmt.Sprintf("!%s", condition)
+			}
+
+			return condition
+		})
+		line = typeProp.ReplaceAllString(line, "`$${store.$$id}/$${name}`")
+		line = payloadProp.ReplaceAllString(line, "args[0]")
+
+		if state != "" {
+			line = wordPattern(state).ReplaceAllString(line, "${1}store.$$state")
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// cutLast slices the string around the last separator
+func cutLast(str string, sep string) (string, string, bool) {
+	index := strings.LastIndex(str, sep)
+	if index < 0 {
+		return "", str, false
+	}
+
+	return str[:index], str[index+len(sep):], true
+}
@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigratePlugins(t *testing.T) {
+	var root = t.TempDir()
+	var index = []string{
+		"import Vuex from 'vuex';",
+		"import cart from './cart';",
+		"",
+		"const logger = (store) => {",
+		"  store.subscribe((mutation, state) => {",
+		"    if (mutation.type === 'cart/add') {",
+		"      console.log(mutation.payload);",
+		"    }",
+		"  });",
+		"};",
+		"",
+		"export default new Vuex.Store({",
+		"  modules: { cart },",
+		"  plugins: [logger],",
+		"});",
+		"",
+	}
+
+	writeModule(t, root, ".", map[string]string{"index.js": strings.Join(index, "\n")})
+	writeModule(t, root, "cart", cartModule)
+
+	if _, err := Migrate(context.Background(), root, Config{NoCache: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, "index.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertContains(t, "index.js", string(content),
+		"import { createPinia } from 'pinia';",
+		"import { logger } from './pinia-plugins';",
+		"pinia.use(logger);",
+		"export default pinia;",
+	)
+
+	content, err = os.ReadFile(filepath.Join(root, "pinia-plugins.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertContains(t, "pinia-plugins.js", string(content),
+		"export function logger({ store }) {",
+		"  store.$onAction(({ name, args, after }) => {",
+		"if ((store.$id === 'cart' && name === 'add')) {",
+		"console.log(args[0]);",
+	)
+}
@@ -0,0 +1,258 @@
+package parser
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var rerunPattern = map[string]*regexp.Regexp{
+	string("define_store"):   regexp.MustCompile(`\bdefineStore\(`),
+	string("generated_file"): regexp.MustCompile(`^(index|actions|getters|state|mutations|vuex-bridge)(\.spec)?\.(ts|js)$`),
+	string("generated_root"): regexp.MustCompile(`^(index|pinia-plugins)\.(ts|js)$`),
+}
+
+// directory of the store keeping the vuex files of the migrated modules
+const ORIGINALS_DIR = ".vuex2pinia"
+
+// isOriginalsDir checks if the walked entry is the originals directory,
+// which is not part of the store
+func isOriginalsDir(entry fs.DirEntry) bool {
+	return entry.IsDir() && entry.Name() == ORIGINALS_DIR
+}
+
+// isPiniaModule checks if the module was migrated by a previous run, the
+// entrypoint defines a store and there is no mutations file anymore
+func isPiniaModule(filesMap map[string]*os.File) bool {
+	index, ok := filesMap["index"]
+	if !ok {
+		return false
+	}
+
+	if _, ok := filesMap["mutations"]; ok {
+		return false
+	}
+
+	content, err := os.ReadFile(index.Name())
+
+	return err == nil && rerunPattern["define_store"].Match(content)
+}
+
+// saveSources copies the vuex files of the migrated modules to the
+// originals directory before any pass rewrites them, the modules migrated
+// by a previous run are skipped
+func (mg *migration) saveSources() error {
+	rootPath, _, err := readRootStore(mg.root)
+	if err != nil {
+		return err
+	}
+
+	return filepath.WalkDir(mg.root, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && isOriginalsDir(entry) {
+			return filepath.SkipDir
+		}
+
+		if err != nil || !entry.IsDir() {
+			return err
+		}
+
+		files, err := mg.vuexSources(path, rootPath)
+		if err != nil || len(files) == 0 {
+			return err
+		}
+
+		return saveOriginals(mg.root, mg.modulePathOf(files[0]), files)
+	})
+}
+
+// vuexSources returns the source files of a directory which are migrated,
+// none when the directory is a module migrated by a previous run, which is
+// recorded
+func (mg *migration) vuexSources(dir string, rootPath string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files = []string{}
+	var filesMap = map[string]*os.File{}
+
+	for _, entry := range entries {
+		var name = entry.Name()
+		var path = filepath.Join(dir, name)
+
+		if entry.IsDir() || !(strings.HasSuffix(name, ".ts") || strings.HasSuffix(name, ".js")) {
+			continue
+		}
+
+		// the pinia bootstrap and plugins created by a previous run
+		if dir == mg.root && path != rootPath && mg.config.Framework != NUXT2_FRAMEWORK && rerunPattern["generated_root"].MatchString(name) {
+			continue
+		}
+
+		if !mg.isMigrated(mg.sourceModulePath(path)) {
+			continue
+		}
+
+		if filename := removeExtension(name); filename == "index" || filename == "mutations" {
+			file, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			defer file.Close()
+
+			filesMap[filename] = file
+		}
+
+		files = append(files, path)
+	}
+
+	if isPiniaModule(filesMap) {
+		mg.piniaModules[mg.modulePathOf(filesMap["index"].Name())] = true
+		return nil, nil
+	}
+
+	return files, nil
+}
+
+// sourceModulePath returns the path of the module defined by a source file
+// before the store is rewritten
+func (mg *migration) sourceModulePath(path string) string {
+	if mg.config.Framework != NUXT2_FRAMEWORK {
+		return mg.modulePathOf(path)
+	}
+
+	return mg.modulePathOf(filepath.Join(mg.nuxtModuleDir(path), "index"))
+}
+
+// isUnchanged checks if the module is known to be unchanged since the
+// previous run
+func (mg *migration) isUnchanged(modulePath string) bool {
+	return len(mg.config.Changed) > 0 && !slices.Contains(mg.config.Changed, modulePath)
+}
+
+// ModulePathOf returns the path of the module defined by a file or a
+// directory of a vuex store
+func ModulePathOf(root string, path string, config Config) string {
+	var mg = newMigration(root, config)
+
+	// a directory, which may be removed
+	if filepath.Ext(path) == "" {
+		return mg.modulePathOf(filepath.Join(path, "index"))
+	}
+
+	return mg.sourceModulePath(path)
+}
+
+// saveOriginals copies the vuex files of the module to the originals
+// directory
+func saveOriginals(root string, modulePath string, files []string) error {
+	var dir = filepath.Join(root, ORIGINALS_DIR, modulePath)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(path)), content, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreOriginals replaces the files created for the migrated modules by
+// the vuex files kept in the originals directory
+func (mg *migration) restoreOriginals() error {
+	var originals = filepath.Join(mg.root, ORIGINALS_DIR)
+	if !fileExists(originals) {
+		return nil
+	}
+
+	var restored = map[string]bool{}
+
+	err := filepath.WalkDir(originals, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(originals, path)
+		if err != nil {
+			return err
+		}
+
+		var dirs = []string{filepath.Join(mg.root, filepath.Dir(rel))}
+		// the nuxt store files are split into module directories
+		if mg.config.Framework == NUXT2_FRAMEWORK {
+			dirs = append(dirs, mg.nuxtModuleDir(filepath.Join(mg.root, rel)))
+		}
+
+		for _, dir := range dirs {
+			if restored[dir] {
+				continue
+			}
+
+			if err := mg.removeGenerated(dir); err != nil {
+				return err
+			}
+
+			restored[dir] = true
+
+			mg.verbosef("Restored %s store", mg.modulePathOf(filepath.Join(dir, "index")))
+		}
+
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(mg.root, rel)), 0755); err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(filepath.Join(mg.root, rel), content, 0644)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(originals)
+}
+
+// removeGenerated removes the files created by the migration in a directory
+func (mg *migration) removeGenerated(dir string) error {
+	// the files of the root store directory are not all created
+	var generated = rerunPattern["generated_file"]
+	if dir == mg.root {
+		generated = rerunPattern["generated_root"]
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for _, created := range entries {
+		var name = created.Name()
+		// the declarations of the split nuxt store files
+		var helpers = mg.config.Framework == NUXT2_FRAMEWORK && removeExtension(name) == NUXT_HELPERS
+
+		if !created.IsDir() && (generated.MatchString(name) || helpers) {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
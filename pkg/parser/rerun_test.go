@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateSavesSourcesBeforeRewrite(t *testing.T) {
+	var root = t.TempDir()
+	var index = "import Vue from 'vue';\nimport Vuex from 'vuex';\nimport cart from './cart';\n\nVue.use(Vuex);\n\nconst store = new Vuex.Store({\n  modules: { cart },\n});\n\nif (module.hot) {\n  module.hot.accept(['./cart'], () => {\n    store.hotUpdate({ modules: { cart } });\n  });\n}\n\nexport default store;\n"
+
+	writeModule(t, root, ".", map[string]string{"index.js": index})
+	writeModule(t, root, "cart", cartModule)
+
+	if _, err := Migrate(context.Background(), root, Config{HMR: true, NoCache: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, ORIGINALS_DIR, "index.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the hot update code is removed after the sources are saved
+	if string(content) != index {
+		t.Errorf("root store saved as\n%s", content)
+	}
+
+	for name, source := range cartModule {
+		content, err := os.ReadFile(filepath.Join(root, ORIGINALS_DIR, "cart", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(content) != source {
+			t.Errorf("cart/%s saved as\n%s", name, content)
+		}
+	}
+
+	// the restored sources are migrated again
+	if _, err := Migrate(context.Background(), root, Config{HMR: true, NoCache: true, Force: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err = os.ReadFile(filepath.Join(root, ORIGINALS_DIR, "index.js"))
+	if err != nil || string(content) != index {
+		t.Errorf("root store saved again as\n%s (%v)", content, err)
+	}
+}
+
+func TestSaveSourcesSkipsModules(t *testing.T) {
+	var root = t.TempDir()
+	writeModule(t, root, "cart", cartModule)
+	writeModule(t, root, "user", map[string]string{"state.js": "export default () => ({});\n"})
+	// migrated by a previous run
+	writeModule(t, root, "auth", map[string]string{"index.js": "export const useAuthStore = defineStore('auth', {});\n"})
+
+	var mg = newMigration(root, Config{Modules: []string{"cart", "auth"}})
+	if err := mg.saveSources(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !fileExists(filepath.Join(root, ORIGINALS_DIR, "cart", "mutations.js")) {
+		t.Error("cart files were not saved")
+	}
+
+	for _, modulePath := range []string{"user", "auth"} {
+		if fileExists(filepath.Join(root, ORIGINALS_DIR, modulePath)) {
+			t.Errorf("%s files were saved", modulePath)
+		}
+	}
+}
+
+func TestModulePathOf(t *testing.T) {
+	var root = "/store"
+	var tests = []struct {
+		path      string
+		framework string
+		expected  string
+	}{
+		{"/store/cart/actions.js", "", "cart"},
+		{"/store/cart/items/state.ts", "", "cart/items"},
+		{"/store/index.js", "", "."},
+		{"/store/cart/items", "", "cart/items"},
+		{"/store/cart.js", NUXT2_FRAMEWORK, "cart"},
+		{"/store/cart/items.js", NUXT2_FRAMEWORK, "cart/items"},
+		{"/store/cart/state.js", NUXT2_FRAMEWORK, "cart"},
+		{"/store/index.js", NUXT2_FRAMEWORK, NUXT_ROOT_MODULE},
+	}
+
+	for _, test := range tests {
+		if modulePath := ModulePathOf(root, test.path, Config{Framework: test.framework}); modulePath != test.expected {
+			t.Errorf("ModulePathOf(%s, %q) = %s, expected %s", test.path, test.framework, modulePath, test.expected)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var rootStorePattern = map[string]*regexp.Regexp{
+	string("option"):       regexp.MustCompile(`^(\w+)\s*(:\s*([\s\S]*))?$`),
+	string("state_method"): regexp.MustCompile(`^state\s*\(\)\s*\{`),
+	string("factory"):      regexp.MustCompile(`^(\(\)\s*=>|function\s*\w*\s*\(\)\s*\{)`),
+	string("inline_block"): regexp.MustCompile(`^((async\s+)?\w+\s*\([^)]*\)(\:\s[^{]+?)?|\w+:\s*(async\s+)?(function\s*)?(\([^)]*\)|\w+)(\:\s[^{=]+?)?(\s*=>)?)\s*\{(.*)\}$`),
+}
+
+const (
+	// module created from the state, getters, mutations and actions of the
+	// root store
+	ROOT_MODULE = "root"
+)
+
+// options of the root store migrated with the modules or the plugins
+var rootStoreOptions = []string{"state", "getters", "mutations", "actions", "modules", "plugins"}
+
+// splitRootStore moves the state, getters, mutations and actions declared
+// inline in the root store into the root module, which is translated as the
+// other modules
+func (mg *migration) splitRootStore() error {
+	rootPath, lines, err := readRootStore(mg.root)
+	if err != nil || rootPath == "" {
+		return err
+	}
+
+	files, moved := mg.rootStoreMembers(rootPath, lines)
+	if len(files) == 0 {
+		return nil
+	}
+
+	var moduleDir = filepath.Join(mg.root, ROOT_MODULE)
+
+	if fileExists(moduleDir) && !mg.isSplitRootModule(moduleDir) {
+		mg.addDiagnostic(rootPath, 0, "%s module already exists, the root %s must be moved to a store by hand", ROOT_MODULE, strings.Join(moved, ", "))
+		return nil
+	}
+
+	// the root module created by a previous run
+	if err := os.RemoveAll(moduleDir); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(moduleDir, 0755); err != nil {
+		return err
+	}
+
+	for _, name := range moved {
+		var path = filepath.Join(moduleDir, fmt.Sprint(name, filepath.Ext(rootPath)))
+
+		if err := os.WriteFile(path, []byte(strings.Join(files[name], "\n")), 0644); err != nil {
+			return err
+		}
+	}
+
+	mg.splitRoot = true
+	mg.addDiagnostic(rootPath, 0, "root %s moved to the %s store", strings.Join(moved, ", "), ROOT_MODULE)
+
+	return nil
+}
+
+// rootStoreMembers returns the files of the root module created from the
+// state, getters, mutations and actions declared inline in the root store,
+// and the names of the members in the order of the store. The options which
+// can not be moved are reported
+func (mg *migration) rootStoreMembers(rootPath string, lines []string) (map[string][]string, []string) {
+	var content = strings.Join(lines, "\n")
+	var loc = pluginPattern["root_store"].FindStringIndex(content)
+
+	args, end := splitCallArgs(content, loc[1])
+	if end < 0 || len(args) == 0 || !strings.HasPrefix(args[0], "{") {
+		mg.addDiagnostic(rootPath, 0, "root store options are not declared inline, its state, getters, mutations and actions must be migrated by hand")
+		return nil, nil
+	}
+
+	options, _ := splitCallArgs(args[0], 1)
+
+	var files = map[string][]string{}
+	var moved = []string{}
+
+	for _, option := range options {
+		var name, value = option, ""
+
+		if match := rootStorePattern["option"].FindStringSubmatch(option); match != nil {
+			name, value = match[1], strings.TrimSpace(match[3])
+		} else if rootStorePattern["state_method"].FindStringSubmatch(option) != nil {
+			name, value = "state", fmt.Sprint("function ", option)
+		}
+
+		if !isRootMember(name) {
+			if !slices.Contains(rootStoreOptions, name) {
+				mg.addDiagnostic(rootPath, 0, "root store option %s has no pinia equivalent, it is removed", name)
+			}
+
+			continue
+		}
+
+		var file []string
+		if name == "state" {
+			file = rootStateFile(value)
+		} else if props, ok := objectProps(value); ok {
+			file = append([]string{"export default {"}, props...)
+			file = append(file, "};", "")
+		}
+
+		if file == nil {
+			mg.addDiagnostic(rootPath, 0, "root %s are not declared inline, they are removed and must be moved to a store by hand", name)
+			continue
+		}
+
+		files[name] = file
+		moved = append(moved, name)
+	}
+
+	return files, moved
+}
+
+// isRootMember checks if the root store option is translated into the root
+// module
+func isRootMember(name string) bool {
+	return name == "state" || name == "getters" || name == "mutations" || name == "actions"
+}
+
+// isSplitRootModule checks if the root module was created from the root
+// store by a previous run, which is migrated to pinia and not kept as an
+// original
+func (mg *migration) isSplitRootModule(moduleDir string) bool {
+	var filesMap = map[string]*os.File{}
+
+	for _, ext := range []string{".ts", ".js"} {
+		for _, name := range []string{"index", "mutations"} {
+			if file, err := os.Open(filepath.Join(moduleDir, fmt.Sprint(name, ext))); err == nil {
+				defer file.Close()
+				filesMap[name] = file
+			}
+		}
+	}
+
+	return isPiniaModule(filesMap) && !fileExists(filepath.Join(mg.root, ORIGINALS_DIR, ROOT_MODULE))
+}
+
+// rootStateFile returns the lines of the state file of the root module, nil
+// when the state is not declared inline
+func rootStateFile(value string) []string {
+	if rootStorePattern["factory"].FindStringSubmatch(value) != nil {
+		var lines = dedent(strings.Split(value, "\n"), 0)
+		lines[0] = fmt.Sprint("export default ", lines[0])
+		lines[len(lines)-1] = fmt.Sprint(lines[len(lines)-1], ";")
+
+		return append(lines, "")
+	}
+
+	props, ok := objectProps(value)
+	if !ok {
+		return nil
+	}
+
+	var lines = append([]string{"export default () => ({"}, props...)
+
+	return append(lines, "});", "")
+}
+
+// objectProps returns the properties of an object literal, one per line
+// with the indentation of a module file. Functions with a block body are
+// written in multiple lines
+func objectProps(value string) ([]string, bool) {
+	if !strings.HasPrefix(value, "{") || !strings.HasSuffix(value, "}") {
+		return nil, false
+	}
+
+	var lines = []string{}
+
+	props, _ := splitCallArgs(value, 1)
+
+	for _, prop := range props {
+		var propLines = strings.Split(prop, "\n")
+
+		if len(propLines) > 1 {
+			propLines = dedent(propLines, 2)
+			propLines[0] = fmt.Sprint("  ", propLines[0])
+			propLines[len(propLines)-1] = fmt.Sprint(propLines[len(propLines)-1], ",")
+
+			lines = append(lines, propLines...)
+			continue
+		}
+
+		match := rootStorePattern["inline_block"].FindStringSubmatch(prop)
+		if match == nil {
+			lines = append(lines, fmt.Sprintf("  %s,", prop))
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf("  %s {", strings.TrimSpace(match[1])))
+
+		for _, statement := range splitStatements(match[9]) {
+			lines = append(lines, fmt.Sprintf("    %s", statement))
+		}
+
+		lines = append(lines, "  },")
+	}
+
+	return lines, true
+}
+
+// dedent moves the lines following the first one of a value to the given
+// indentation, the closing line has the indentation of the value
+func dedent(lines []string, indent int) []string {
+	var last = lines[len(lines)-1]
+	var shift = len(last) - len(strings.TrimLeft(last, " ")) - indent
+	var result = []string{lines[0]}
+
+	for _, line := range lines[1:] {
+		if shift > 0 && strings.HasPrefix(line, strings.Repeat(" ", shift)) {
+			line = line[shift:]
+		}
+
+		result = append(result, line)
+	}
+
+	return result
+}
+
+// splitStatements returns the statements of a block written in one line
+func splitStatements(block string) []string {
+	var statements = []string{}
+	var depth = 0
+	var quote byte
+	var start = 0
+
+	for index := 0; index < len(block); index++ {
+		var char = block[index]
+
+		if quote != 0 {
+			if char == '\\' {
+				index++
+			} else if char == quote {
+				quote = 0
+			}
+
+			continue
+		}
+
+		switch char {
+		case '"', '\'', '`':
+			quote = char
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ';':
+			if depth == 0 {
+				statements = append(statements, strings.TrimSpace(block[start:index+1]))
+				start = index + 1
+			}
+		}
+	}
+
+	if rest := strings.TrimSpace(block[start:]); rest != "" {
+		statements = append(statements, rest)
+	}
+
+	return statements
+}
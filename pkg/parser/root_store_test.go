@@ -0,0 +1,127 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestObjectProps(t *testing.T) {
+	var tests = []struct {
+		value    string
+		expected []string
+	}{
+		{"{ user: null, locale: 'en' }", []string{"  user: null,", "  locale: 'en',"}},
+		{"{ isLogged: (state) => state.user !== null }", []string{"  isLogged: (state) => state.user !== null,"}},
+		{"{ setUser(state, u) { state.user = u; } }", []string{"  setUser(state, u) {", "    state.user = u;", "  },"}},
+		{"{ reset: (state) => { state.a = 1; state.b = { c: 2 }; } }", []string{"  reset: (state) => {", "    state.a = 1;", "    state.b = { c: 2 };", "  },"}},
+		{"{\n    setUser(state, u) {\n      state.user = u;\n    },\n  }", []string{"  setUser(state, u) {", "    state.user = u;", "  },"}},
+	}
+
+	for _, test := range tests {
+		if result, ok := objectProps(test.value); !ok || !slices.Equal(result, test.expected) {
+			t.Errorf("objectProps(%q) = %q, expected %q", test.value, result, test.expected)
+		}
+	}
+
+	if _, ok := objectProps("rootGetters"); ok {
+		t.Errorf("objectProps(rootGetters) is not an object")
+	}
+}
+
+func TestRootStateFile(t *testing.T) {
+	var tests = []struct {
+		value    string
+		expected []string
+	}{
+		{"{ user: null }", []string{"export default () => ({", "  user: null,", "});", ""}},
+		{"() => ({\n    user: null,\n  })", []string{"export default () => ({", "  user: null,", "});", ""}},
+		{"state", nil},
+	}
+
+	for _, test := range tests {
+		if result := rootStateFile(test.value); !slices.Equal(result, test.expected) {
+			t.Errorf("rootStateFile(%q) = %q, expected %q", test.value, result, test.expected)
+		}
+	}
+}
+
+func TestSplitRootStore(t *testing.T) {
+	var root = t.TempDir()
+	var index = strings.Join([]string{
+		"import Vuex from 'vuex';",
+		"export default new Vuex.Store({",
+		"  strict: true,",
+		"  state: { user: null },",
+		"  getters: rootGetters,",
+		"  mutations: { setUser(state, u) { state.user = u; } },",
+		"});",
+	}, "\n")
+
+	if err := os.WriteFile(filepath.Join(root, "index.js"), []byte(index), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mg = newMigration(root, Config{})
+	if err := mg.splitRootStore(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !mg.splitRoot {
+		t.Fatalf("root store members were not moved")
+	}
+
+	for _, name := range []string{"state.js", "mutations.js"} {
+		if !fileExists(filepath.Join(root, ROOT_MODULE, name)) {
+			t.Errorf("%s was not created", name)
+		}
+	}
+
+	var messages = []string{}
+	for _, diagnostic := range mg.diagnostics {
+		messages = append(messages, diagnostic.Message)
+	}
+
+	for _, expected := range []string{"root store option strict", "root getters are not declared inline", "root state, mutations moved"} {
+		if !slices.ContainsFunc(messages, func(message string) bool { return strings.HasPrefix(message, expected) }) {
+			t.Errorf("diagnostic %q not found in %q", expected, messages)
+		}
+	}
+}
+
+func TestMigrateStateOnlyRootStore(t *testing.T) {
+	var root = t.TempDir()
+	writeModule(t, root, ".", map[string]string{"index.js": strings.Join([]string{
+		"import Vuex from 'vuex';",
+		"import cart from './cart';",
+		"export default new Vuex.Store({",
+		"  state: { version: 1 },",
+		"  modules: { cart },",
+		"});",
+	}, "\n")})
+	writeModule(t, root, "cart", map[string]string{
+		"index.js":   "import getters from './getters';\n\nexport default {\n  namespaced: true,\n  getters,\n};\n",
+		"getters.js": "export default {\n  label: (state, getters, rootState) => `v${rootState.version}`,\n};\n",
+	})
+
+	if _, err := Migrate(context.Background(), root, Config{NoCache: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, ROOT_MODULE, "index.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertContains(t, "root/index.js", string(content), "export const useRootStore = defineStore('root', {\n  state,\n});")
+
+	content, err = os.ReadFile(filepath.Join(root, "cart", "getters.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertContains(t, "cart/getters.js", string(content), "import { useRootStore } from '~/stores/root'", "rootStore.version")
+}
@@ -0,0 +1,281 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var statePattern = map[string]*regexp.Regexp{
+	string("arrow_factory"):    regexp.MustCompile(`^((export\s+default|(export\s+)?const\s+state\s*=)\s*)\(\)\s*=>\s*\(\{\s*$`),
+	string("function_factory"): regexp.MustCompile(`^(export\s+default\s+function(\s+\w+)?\s*|(export\s+)?function\s+state\s*)\(\)\s*\{$`),
+	string("return_object"):    regexp.MustCompile(`^\s*return\s*\{\s*$`),
+	string("line_comment"):     regexp.MustCompile(`(^|\s)//.*$`),
+	string("string"):           regexp.MustCompile("^('.*'|\".*\"|`.*`)$"),
+	string("number"):           regexp.MustCompile(`^-?(\d+(\.\d+)?|\.\d+)(e-?\d+)?$`),
+	string("boolean"):          regexp.MustCompile(`^(true|false)$`),
+	string("nullish"):          regexp.MustCompile(`^(null|undefined)$`),
+	string("instance"):         regexp.MustCompile(`^new\s+(\w+)\(`),
+	string("quoted_key"):       regexp.MustCompile(`^('|")(.+)('|")$`),
+	string("identifier"):       regexp.MustCompile(`^[A-Za-z_$][\w$]*$`),
+}
+
+// comment added to the types that could not be inferred from the value
+const TODO_TYPE = "// TODO set the type"
+
+// field of the state, with the type inferred from the initial value
+type stateField struct {
+	key       string
+	valueType string
+	// the type must be checked by hand
+	todo bool
+}
+
+// typeStateFile rewrites a javascript state file as typescript, returning the
+// path of the new file, or an empty path when the state is not a literal
+func (mg *migration) typeStateFile(path string, interfaceName string) (string, error) {
+	lines, header, fields, err := mg.readStateFactory(path)
+	if err != nil || header < 0 {
+		return "", err
+	}
+
+	if match := statePattern["arrow_factory"].FindStringSubmatch(lines[header]); match != nil {
+		lines[header] = fmt.Sprintf("%s(): %s => ({", match[1], interfaceName)
+	} else if match := statePattern["function_factory"].FindStringSubmatch(lines[header]); match != nil {
+		lines[header] = fmt.Sprintf("%s(): %s {", strings.TrimRight(match[1], " "), interfaceName)
+	}
+
+	var definition = append([]string{fmt.Sprintf("export interface %s {", interfaceName)}, objectType(fields, "  ")...)
+	definition = append(definition, "}", "")
+
+	var tsPath = fmt.Sprint(strings.TrimSuffix(path, ".js"), ".ts")
+	if err := os.WriteFile(tsPath, []byte(strings.Join(slices.Insert(lines, header, definition...), "\n")), 0644); err != nil {
+		return "", err
+	}
+
+	mg.warnStateTodos(tsPath, fields)
+
+	return tsPath, os.Remove(path)
+}
+
+// jsdocStateFile documents the state of a javascript state file with a JSDoc
+// typedef inferred from the initial values
+func (mg *migration) jsdocStateFile(path string, typeName string) error {
+	lines, header, fields, err := mg.readStateFactory(path)
+	if err != nil || header < 0 {
+		return err
+	}
+
+	var definition = []string{"/**", fmt.Sprintf(" * @typedef {Object} %s", typeName)}
+
+	for _, field := range fields {
+		var property = fmt.Sprintf(" * @property {%s} %s", strings.Join(strings.Fields(field.valueType), " "), strings.Trim(field.key, `'"`))
+		if field.todo {
+			property = fmt.Sprint(property, " TODO set the type")
+		}
+
+		definition = append(definition, property)
+	}
+
+	definition = append(definition, " */", "", fmt.Sprintf("/** @returns {%s} */", typeName))
+
+	if err := os.WriteFile(path, []byte(strings.Join(slices.Insert(lines, header, definition...), "\n")), 0644); err != nil {
+		return err
+	}
+
+	mg.warnStateTodos(path, fields)
+
+	return nil
+}
+
+// readStateFactory returns the lines of a state file, the index of the line
+// declaring the state factory and the fields of the returned object. The
+// index is negative when the state is not an object literal
+func (mg *migration) readStateFactory(path string) ([]string, int, []stateField, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, -1, nil, err
+	}
+
+	var lines = strings.Split(string(content), "\n")
+	var header, start = -1, -1
+
+	for index, line := range lines {
+		if statePattern["arrow_factory"].FindStringSubmatch(line) != nil {
+			header, start = index, index
+			break
+		}
+
+		if statePattern["function_factory"].FindStringSubmatch(line) != nil {
+			header = index
+
+			for returnIndex := index + 1; returnIndex < len(lines); returnIndex++ {
+				if statePattern["return_object"].FindStringSubmatch(lines[returnIndex]) != nil {
+					start = returnIndex
+					break
+				}
+			}
+
+			break
+		}
+	}
+
+	if start < 0 {
+		mg.addDiagnostic(path, 0, "state is not an object literal, the type can not be inferred")
+		return lines, -1, nil, nil
+	}
+
+	var literal = []string{}
+	for index, balance := start+1, 1; index < len(lines) && balance > 0; index++ {
+		var line = statePattern["line_comment"].ReplaceAllString(lines[index], "")
+
+		balance += bracketBalance(line)
+		literal = append(literal, line)
+	}
+
+	return lines, header, objectFields(fmt.Sprint("{", strings.Join(literal, "\n")), "  "), nil
+}
+
+func (mg *migration) warnStateTodos(path string, fields []stateField) {
+	if slices.ContainsFunc(fields, func(field stateField) bool { return field.todo }) {
+		mg.addDiagnostic(path, 0, "some state types could not be inferred, check the TODO comments")
+	}
+}
+
+// objectFields returns the fields of an object literal
+func objectFields(literal string, indent string) []stateField {
+	var fields = []stateField{}
+	props, _ := splitCallArgs(literal, strings.Index(literal, "{")+1)
+
+	for _, prop := range props {
+		if strings.HasPrefix(prop, "...") {
+			fields = append(fields, stateField{key: "[key: string]", valueType: "unknown", todo: true})
+			continue
+		}
+
+		key, value, ok := cutTopLevel(prop, ':')
+		key = strings.TrimSpace(key)
+
+		if match := statePattern["quoted_key"].FindStringSubmatch(key); match != nil && statePattern["identifier"].FindStringSubmatch(match[2]) != nil {
+			key = match[2]
+		}
+
+		if !ok {
+			// shorthand property
+			fields = append(fields, stateField{key: key, valueType: "unknown", todo: true})
+			continue
+		}
+
+		var valueType, todo = inferValueType(strings.TrimSpace(value), indent)
+		fields = append(fields, stateField{key: key, valueType: valueType, todo: todo})
+	}
+
+	return fields
+}
+
+// objectType returns the lines of the interface of an object literal
+func objectType(fields []stateField, indent string) []string {
+	var lines = []string{}
+
+	for _, field := range fields {
+		if field.todo {
+			lines = append(lines, fmt.Sprintf("%s%s: %s; %s", indent, field.key, field.valueType, TODO_TYPE))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s%s: %s;", indent, field.key, field.valueType))
+		}
+	}
+
+	return lines
+}
+
+// inferValueType returns the type of a literal value, and if the type must
+// be checked by hand
+func inferValueType(value string, indent string) (string, bool) {
+	switch {
+	case statePattern["string"].FindStringSubmatch(value) != nil:
+		return "string", false
+	case statePattern["number"].FindStringSubmatch(value) != nil:
+		return "number", false
+	case statePattern["boolean"].FindStringSubmatch(value) != nil:
+		return "boolean", false
+	case statePattern["nullish"].FindStringSubmatch(value) != nil:
+		return fmt.Sprintf("unknown | %s", value), true
+	case strings.HasPrefix(value, "{") && strings.HasSuffix(value, "}"):
+		var fields = objectFields(value, fmt.Sprint(indent, "  "))
+		if len(fields) == 0 {
+			return "Record<string, unknown>", true
+		}
+
+		return fmt.Sprintf("{\n%s\n%s}", strings.Join(objectType(fields, fmt.Sprint(indent, "  ")), "\n"), indent), false
+	case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+		items, _ := splitCallArgs(value, 1)
+		if len(items) == 0 {
+			return "unknown[]", true
+		}
+
+		var types = []string{}
+		var todo = false
+
+		for _, item := range items {
+			itemType, itemTodo := inferValueType(item, indent)
+			todo = todo || itemTodo
+
+			if !slices.Contains(types, itemType) {
+				types = append(types, itemType)
+			}
+		}
+
+		if len(types) == 1 && !strings.Contains(types[0], "|") {
+			return fmt.Sprint(types[0], "[]"), todo
+		}
+
+		return fmt.Sprintf("(%s)[]", strings.Join(types, " | ")), todo
+	}
+
+	if match := statePattern["instance"].FindStringSubmatch(value); match != nil {
+		switch match[1] {
+		case "Map":
+			return "Map<unknown, unknown>", true
+		case "Set":
+			return "Set<unknown>", true
+		}
+
+		return match[1], false
+	}
+
+	return "unknown", true
+}
+
+// cutTopLevel slices the expression around the first separator that is not
+// nested inside brackets or strings
+func cutTopLevel(expr string, separator rune) (string, string, bool) {
+	var depth = 0
+	var quote rune = 0
+
+	for index, char := range expr {
+		if quote != 0 {
+			if char == quote {
+				quote = 0
+			}
+
+			continue
+		}
+
+		switch char {
+		case '\'', '"', '`':
+			quote = char
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case separator:
+			if depth == 0 {
+				return expr[:index], expr[index+1:], true
+			}
+		}
+	}
+
+	return expr, "", false
+}
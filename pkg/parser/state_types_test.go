@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var typedState = "export default () => ({\n  items: [],\n  total: 0,\n  name: 'cart',\n  open: false,\n  user: null,\n  meta: { id: 1, tags: ['a'] },\n});\n"
+
+func TestInferValueType(t *testing.T) {
+	var tests = []struct {
+		value    string
+		expected string
+		todo     bool
+	}{
+		{"'cart'", "string", false},
+		{"-1.5", "number", false},
+		{"true", "boolean", false},
+		{"null", "unknown | null", true},
+		{"[]", "unknown[]", true},
+		{"[1, 2]", "number[]", false},
+		{"[1, 'a']", "(number | string)[]", false},
+		{"{}", "Record<string, unknown>", true},
+		{"{ id: 1 }", "{\n  id: number;\n}", false},
+		{"new Date()", "Date", false},
+		{"new Map()", "Map<unknown, unknown>", true},
+		{"items.length", "unknown", true},
+	}
+
+	for _, test := range tests {
+		if valueType, todo := inferValueType(test.value, ""); valueType != test.expected || todo != test.todo {
+			t.Errorf("inferValueType(%s) = %q, %t", test.value, valueType, todo)
+		}
+	}
+}
+
+func TestCutTopLevel(t *testing.T) {
+	before, after, ok := cutTopLevel("items: [1, 2], name: 'a, b'", ',')
+	if before != "items: [1, 2]" || after != " name: 'a, b'" || !ok {
+		t.Errorf("cutTopLevel = %q, %q, %t", before, after, ok)
+	}
+
+	if _, _, ok := cutTopLevel("name: 'a, b'", ','); ok {
+		t.Error("separator found inside a string")
+	}
+}
+
+func TestMigrateTypedState(t *testing.T) {
+	var migrated = migratedModule(t, map[string]string{"state.js": typedState}, Config{TypedState: true})
+
+	if _, ok := migrated["state.js"]; ok {
+		t.Error("state.js was not removed")
+	}
+
+	var expected = "export interface CartState {\n  items: unknown[]; // TODO set the type\n  total: number;\n  name: string;\n  open: boolean;\n  user: unknown | null; // TODO set the type\n  meta: {\n    id: number;\n    tags: string[];\n  };\n}\n\n" + strings.Replace(typedState, "() =>", "(): CartState =>", 1)
+
+	if migrated["state.ts"] != expected {
+		t.Errorf("state.ts migrated as\n%s", migrated["state.ts"])
+	}
+}
+
+func TestTypeStateFileKeepsFactories(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "state.js")
+	if err := os.WriteFile(path, []byte("export default () => createState();\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	typedPath, err := newMigration(filepath.Dir(path), Config{}).typeStateFile(path, "CartState")
+	if err != nil || typedPath != "" {
+		t.Errorf("typeStateFile = %q, %v", typedPath, err)
+	}
+}
@@ -0,0 +1,179 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var storePattern = map[string]*regexp.Regexp{
+	string("root_state_call"):  regexp.MustCompile(`\brootState\.(\w+)(\.(\w+))?`),
+	string("root_getter_call"): regexp.MustCompile(`\brootGetters\[("|')((\w+\/)+)(\w+)("|')\]`),
+	string("global_getter"):    regexp.MustCompile(`\b(getters|rootGetters)(\.(\w+)|\[("|')(\w+)("|')\])`),
+}
+
+// crossStores keeps track of the stores referenced by a translated file,
+// imports are added once per file and instances once per function
+type crossStores struct {
+	imports      []string
+	instantiated []string
+	mg           *migration
+}
+
+// storeNames returns the instance name and the function defining the store
+// of a module path, e.g. `auth` or `cart/items`
+func storeNames(modulePath string) (string, string) {
+	var segments = strings.Split(modulePath, "/")
+	var name = segments[len(segments)-1]
+
+	return fmt.Sprint(kebabToCamelCase(name), "Store"), fmt.Sprintf("use%sStore", kebabToCamelCase(name, true))
+}
+
+// importStore adds the import statement of the store of a module path
+func (s *crossStores) importStore(modulePath string) (string, string) {
+	storeName, storeFn := storeNames(modulePath)
+
+	// create import statement of store
+	importLine := fmt.Sprintf("import { %s } from '%s'", storeFn, fmt.Sprint("~/stores/", modulePath))
+	if !slices.Contains(s.imports, importLine) {
+		s.imports = append(s.imports, importLine)
+	}
+
+	return storeName, storeFn
+}
+
+// use returns the instance name of the store, and the line instantiating it
+// when it is the first time the store is used in the current function
+func (s *crossStores) use(modulePath string) (string, string) {
+	storeName, storeFn := s.importStore(modulePath)
+
+	if slices.Contains(s.instantiated, storeName) {
+		return storeName, ""
+	}
+
+	s.instantiated = append(s.instantiated, storeName)
+
+	return storeName, fmt.Sprintf("    const %s = %s()", storeName, storeFn)
+}
+
+// keepVuex returns the line asking to go through the vuex store for the
+// references to a module not migrated, or to the root state when it is
+// still in the vuex store, when it is the first time in the current function
+func (s *crossStores) keepVuex(modulePath string) string {
+	var key = fmt.Sprint("vuex:", modulePath)
+	if slices.Contains(s.instantiated, key) {
+		return ""
+	}
+
+	s.instantiated = append(s.instantiated, key)
+
+	if modulePath == "" {
+		return "    // TODO the root state is still in the vuex store, the references to it must go through the vuex store"
+	}
+
+	return fmt.Sprintf("    // TODO %s is still a vuex module, the references to it must go through the vuex store", modulePath)
+}
+
+// reset must be called when a new function starts
+func (s *crossStores) reset() {
+	s.instantiated = []string{}
+}
+
+// replaceRootRefs rewrites rootState and rootGetters references into the
+// corresponding stores, returning the lines instantiating them
+func (s *crossStores) replaceRootRefs(line string) (string, []string) {
+	var defLines = []string{}
+
+	for _, match := range storePattern["root_state_call"].FindAllStringSubmatch(line, -1) {
+		var modulePath = match[1]
+		var rootProp = false
+
+		// state of the root store, e.g. `rootState.user.name`
+		if _, ok := s.mg.modules[modulePath]; !ok {
+			modulePath, rootProp = ROOT_MODULE, true
+
+			if !s.mg.splitRoot {
+				modulePath = ""
+			}
+		}
+
+		if modulePath == "" || !s.mg.isMigrated(modulePath) {
+			if defLine := s.keepVuex(modulePath); defLine != "" {
+				defLines = append(defLines, defLine)
+			}
+
+			continue
+		}
+
+		storeName, defLine := s.use(modulePath)
+		if defLine != "" {
+			defLines = append(defLines, defLine)
+		}
+
+		var replace = fmt.Sprintf("%s.$state", storeName)
+		if rootProp {
+			replace = fmt.Sprintf("%s.%s%s", storeName, match[1], match[2])
+		} else if match[3] != "" {
+			replace = fmt.Sprintf("%s.%s", storeName, match[3])
+		}
+
+		line = strings.Replace(line, match[0], replace, 1)
+	}
+
+	for _, match := range storePattern["root_getter_call"].FindAllStringSubmatch(line, -1) {
+		var modulePath = s.mg.resolveNamespace(strings.TrimSuffix(match[2], "/"))
+
+		if !s.mg.isMigrated(modulePath) {
+			if defLine := s.keepVuex(modulePath); defLine != "" {
+				defLines = append(defLines, defLine)
+			}
+
+			continue
+		}
+
+		storeName, defLine := s.use(modulePath)
+		if defLine != "" {
+			defLines = append(defLines, defLine)
+		}
+
+		line = strings.Replace(line, match[0], fmt.Sprintf("%s.%s", storeName, match[4]), 1)
+	}
+
+	return line, defLines
+}
+
+// replaceGlobalGetters rewrites references to getters of other modules
+// without namespace, returning the lines instantiating the stores
+func (s *crossStores) replaceGlobalGetters(line string, currentPath string) (string, []string) {
+	var defLines = []string{}
+
+	for _, match := range storePattern["global_getter"].FindAllStringSubmatch(line, -1) {
+		var name = match[3]
+		if name == "" {
+			name = match[5]
+		}
+
+		owners := s.mg.globalOwners(name, true, currentPath, match[1] == "rootGetters")
+		if len(owners) == 0 || owners[0] == currentPath {
+			continue
+		}
+
+		if !s.mg.isMigrated(owners[0]) {
+			if defLine := s.keepVuex(owners[0]); defLine != "" {
+				defLines = append(defLines, defLine)
+			}
+
+			continue
+		}
+
+		storeName, defLine := s.use(owners[0])
+		if defLine != "" {
+			defLines = append(defLines, defLine)
+		}
+
+		line = strings.Replace(line, match[0], fmt.Sprintf("%s.%s", storeName, name), 1)
+	}
+
+	return line, defLines
+}
@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestReplaceRootRefs(t *testing.T) {
+	var mg = newMigration("", Config{})
+	mg.modules["user"] = storeModule{namespaced: true, namespace: "user"}
+	mg.modules["cart/items"] = storeModule{namespaced: true, namespace: "cart/items"}
+
+	var stores = crossStores{mg: mg}
+
+	var tests = []struct {
+		line     string
+		expected string
+		defLines []string
+	}{
+		{"const name = rootState.user.name;", "const name = userStore.name;", []string{"    const userStore = useUserStore()"}},
+		{"return rootState.user;", "return userStore.$state;", []string{}},
+		{"if (rootGetters['cart/items/count'] > 0) {", "if (itemsStore.count > 0) {", []string{"    const itemsStore = useItemsStore()"}},
+	}
+
+	for _, test := range tests {
+		line, defLines := stores.replaceRootRefs(test.line)
+		if line != test.expected || !slices.Equal(defLines, test.defLines) {
+			t.Errorf("replaceRootRefs(%q) = %q, %q", test.line, line, defLines)
+		}
+	}
+
+	if !slices.Equal(stores.imports, []string{"import { useUserStore } from '~/stores/user'", "import { useItemsStore } from '~/stores/cart/items'"}) {
+		t.Errorf("stores imported as %q", stores.imports)
+	}
+
+	// the stores are instantiated again in the next function
+	stores.reset()
+
+	if _, defLines := stores.replaceRootRefs("rootState.user.name"); len(defLines) != 1 {
+		t.Errorf("store not instantiated after reset: %q", defLines)
+	}
+}
+
+func TestStoreNames(t *testing.T) {
+	if name, fn := storeNames("cart/line-items"); name != "lineItemsStore" || fn != "useLineItemsStore" {
+		t.Errorf("storeNames = %s, %s", name, fn)
+	}
+}
+
+func TestReplaceRootRefsKeepsVuexReferences(t *testing.T) {
+	var mg = newMigration("", Config{Modules: []string{"cart"}})
+	mg.modules["user"] = storeModule{namespaced: true, namespace: "user"}
+	mg.modules["cart"] = storeModule{namespaced: true, namespace: "cart"}
+
+	var stores = crossStores{mg: mg}
+
+	var tests = []struct {
+		line     string
+		defLines []string
+	}{
+		{"const name = rootState.user.name;", []string{"    // TODO user is still a vuex module, the references to it must go through the vuex store"}},
+		{"const count = rootGetters['user/count'];", []string{}},
+		{"const version = rootState.version;", []string{"    // TODO the root state is still in the vuex store, the references to it must go through the vuex store"}},
+	}
+
+	for _, test := range tests {
+		line, defLines := stores.replaceRootRefs(test.line)
+		if line != test.line || !slices.Equal(defLines, test.defLines) {
+			t.Errorf("replaceRootRefs(%q) = %q, %q", test.line, line, defLines)
+		}
+	}
+
+	if len(stores.imports) != 0 {
+		t.Errorf("stores imported as %q", stores.imports)
+	}
+}
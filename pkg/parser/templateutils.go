@@ -4,8 +4,9 @@ import (
 	"bytes"
 	"embed"
 	"errors"
+	"fmt"
 	"os"
-	"strings"
+	"path/filepath"
 	"text/template"
 )
 
@@ -24,22 +25,18 @@ var indexStateOnlyTmpl embed.FS
 //go:embed templates/actions_empty.tmpl
 var actionsEmptyTmpl embed.FS
 
+//go:embed templates/pinia.tmpl
+var piniaTmpl embed.FS
+
 const (
 	DEFAULT_TEMPLATE       string = "templates/index.tmpl"
 	NO_ACTIONS_TEMPLATE    string = "templates/index_no_actions.tmpl"
 	NO_GETTERS_TEMPLATE    string = "templates/index_no_getters.tmpl"
 	STATE_ONLY_TEMPLATE    string = "templates/index_state_only.tmpl"
 	ACTIONS_EMPTY_TEMPLATE string = "templates/actions_empty.tmpl"
+	PINIA_TEMPLATE         string = "templates/pinia.tmpl"
 )
 
-func getFirstKey[K comparable, V any](m map[K]V) K {
-	for k := range m {
-		return k
-	}
-
-	return *new(K)
-}
-
 func getTemplate(template string) ([]byte, error) {
 	switch template {
 	case NO_ACTIONS_TEMPLATE:
@@ -50,15 +47,21 @@ func getTemplate(template string) ([]byte, error) {
 		return indexStateOnlyTmpl.ReadFile(STATE_ONLY_TEMPLATE)
 	case ACTIONS_EMPTY_TEMPLATE:
 		return actionsEmptyTmpl.ReadFile(ACTIONS_EMPTY_TEMPLATE)
+	case PINIA_TEMPLATE:
+		return piniaTmpl.ReadFile(PINIA_TEMPLATE)
 	default:
 		return indexTmpl.ReadFile(DEFAULT_TEMPLATE)
 	}
 }
 
-func getTemplatePath(filesMap map[string]*os.File, filename string) string {
-	var key = getFirstKey(filesMap)
+// getTemplatePath returns the path of a file created in the module
+// directory, with the extension of the module language
+func getTemplatePath(filesMap map[string]*os.File, filename string, lang string) string {
+	for _, file := range filesMap {
+		return filepath.Join(filepath.Dir(file.Name()), fmt.Sprint(filename, ".", lang))
+	}
 
-	return strings.Replace(filesMap[key].Name(), key, filename, 1)
+	return ""
 }
 
 func parseTemplate(templateData string, outputPath string, values map[string]string) error {
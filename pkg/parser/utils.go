@@ -2,7 +2,9 @@ package parser
 
 import (
 	"fmt"
+	"os"
 	"reflect"
+	"regexp"
 	"runtime"
 	"strings"
 )
@@ -34,39 +36,27 @@ func kebabToCamelCase(kebab string, firstToUpper ...bool) (camelCase string) {
 	return
 }
 
-// PrintMemUsage outputs the current, total and OS memory being used. As well as the number of garage collection cycles completed.
-func PrintMemUsage() {
+// memUsage returns the current, total and OS memory being used. As well as the number of garage collection cycles completed.
+func memUsage() string {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
 
 	// For info on each, see: https://golang.org/pkg/runtime/#MemStats
-	fmt.Printf("Alloc = %v MiB", bToMb(m.Alloc))
-	fmt.Printf("\tTotalAlloc = %v MiB", bToMb(m.TotalAlloc))
-	fmt.Printf("\tSys = %v MiB", bToMb(m.Sys))
-	fmt.Printf("\tNumGC = %v\n", m.NumGC)
+	return fmt.Sprintf("Alloc = %v MiB\tTotalAlloc = %v MiB\tSys = %v MiB\tNumGC = %v", bToMb(m.Alloc), bToMb(m.TotalAlloc), bToMb(m.Sys), m.NumGC)
 }
 
 func bToMb(b uint64) uint64 {
 	return b / 1024 / 1024
 }
 
-func printOutput(path string, fn func()) {
+func (mg *migration) printOutput(path string, fn func()) {
 	tag := fmt.Sprintf("--------------------%s--------------------", strings.Split(path, "/")[len(strings.Split(path, "/"))-2])
 
-	if Verbose {
-		fmt.Println(tag)
-	}
+	mg.verbosef("%s", tag)
 
 	fn()
 
-	if Verbose {
-		for range tag {
-			fmt.Printf("-")
-		}
-
-		fmt.Println()
-		fmt.Println()
-	}
+	mg.verbosef("%s\n\n", strings.Repeat("-", len(tag)))
 }
 
 func removeExtension(path string) string {
@@ -85,3 +75,17 @@ func insertLine(array []string, index int, value string) []string {
 	array[index] = value
 	return array
 }
+
+// wordPattern matches an identifier that is not a property of another
+// object, the preceding character is kept in the first group
+func wordPattern(word string) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(`(^|[^\w.$])%s\b`, regexp.QuoteMeta(word)))
+}
+
+func fileExists(path string) bool {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,217 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var typePattern = map[string]*regexp.Regexp{
+	string("tree_annotation"): regexp.MustCompile(`^((export\s+)?(const|let)\s+\w+)\s*\:\s*(ActionTree|GetterTree|MutationTree|Module)<(.*)>(\s*=)`),
+	string("context_alias"):   regexp.MustCompile(`^(export\s+)?type\s+(\w+)\s*=\s*ActionContext<(.*)>;?$`),
+	string("context_type"):    regexp.MustCompile(`\s*\:\s*ActionContext<([^<>]*(<[^<>]*>)?)*>`),
+	string("method_start"):    regexp.MustCompile(`^\s{2}(async\s+)?\w+\(`),
+	string("root_state_key"):  regexp.MustCompile(`\bRootState\[('|")([\w/-]+)('|")\]`),
+	string("named_import"):    regexp.MustCompile(`^import\s+(type\s+)?\{([^}]*)\}\s+from\s+('|")(.+)('|");?$`),
+	string("type_names"):      regexp.MustCompile(`\b[A-Z]\w*\b`),
+}
+
+// types exported by vuex, not needed by pinia
+var vuexTypes = []string{"ActionTree", "GetterTree", "MutationTree", "ActionContext", "Module", "ModuleTree", "Commit", "Dispatch", "Getter", "Mutation", "Action", "Store"}
+
+// stripVuexTypes removes the vuex type annotations of the module objects and
+// the action contexts, the types of the state param and the imports that are
+// not used anymore, `RootState['x']` turns into the state of the store
+func (mg *migration) stripVuexTypes(lines []string, filename string) []string {
+	var result = []string{}
+	var aliases = []string{}
+	// type names found in the removed annotations
+	var removed = []string{}
+	var storeImports = []string{}
+	// getters params are replaced by the store
+	var typedParams = 1
+	if removeExtension(getFilename(filename)) == "getters" {
+		typedParams = len(getterParams)
+	}
+
+	for index := 0; index < len(lines); index++ {
+		var line = lines[index]
+
+		if match := typePattern["context_alias"].FindStringSubmatch(line); match != nil {
+			aliases = append(aliases, match[2])
+			removed = append(removed, typePattern["type_names"].FindAllString(match[3], -1)...)
+			index = skipBlankLine(result, lines, index)
+			continue
+		}
+
+		if match := typePattern["tree_annotation"].FindStringSubmatch(line); match != nil {
+			removed = append(removed, typePattern["type_names"].FindAllString(match[5], -1)...)
+			line = typePattern["tree_annotation"].ReplaceAllString(line, "$1$6")
+		}
+
+		for _, match := range typePattern["context_type"].FindAllStringSubmatch(line, -1) {
+			removed = append(removed, typePattern["type_names"].FindAllString(match[1], -1)...)
+		}
+
+		line = typePattern["context_type"].ReplaceAllString(line, "")
+
+		for _, alias := range aliases {
+			line = regexp.MustCompile(fmt.Sprintf(`\s*\:\s*%s\b`, regexp.QuoteMeta(alias))).ReplaceAllString(line, "")
+		}
+
+		if typePattern["method_start"].FindStringSubmatch(line) != nil {
+			var paramTypes string
+			line, paramTypes = stripParamTypes(line, typedParams)
+			removed = append(removed, typePattern["type_names"].FindAllString(paramTypes, -1)...)
+		}
+
+		line = typePattern["root_state_key"].ReplaceAllStringFunc(line, func(key string) string {
+			var modulePath = mg.resolveNamespace(typePattern["root_state_key"].FindStringSubmatch(key)[2])
+			_, storeFn := storeNames(modulePath)
+
+			storeImports = append(storeImports, fmt.Sprintf("import { %s } from '~/stores/%s';", storeFn, modulePath))
+			removed = append(removed, "RootState")
+
+			return fmt.Sprintf("ReturnType<typeof %s>['$state']", storeFn)
+		})
+
+		result = append(result, line)
+	}
+
+	result = mg.removeUnusedImports(result, append(removed, aliases...), filename)
+
+	if len(storeImports) > 0 {
+		appendImports(&result, &storeImports)
+	}
+
+	return result
+}
+
+// stripParamTypes removes the types of the first params of a method, the
+// state of mutations, the context of actions and the params of getters are
+// not params in pinia. The removed types are returned
+func stripParamTypes(line string, count int) (string, string) {
+	var start = strings.Index(line, "(") + 1
+	var depth = 0
+	var colon = -1
+	var removed = []string{}
+
+	for index := start; index < len(line) && count > 0; index++ {
+		switch line[index] {
+		case '(', '[', '{', '<':
+			depth++
+			continue
+		case ':':
+			if depth == 0 && colon < 0 {
+				colon = index
+			}
+
+			continue
+		case ')', ']', '}', '>':
+			if depth > 0 {
+				depth--
+				continue
+			}
+		case ',':
+			if depth > 0 {
+				continue
+			}
+		default:
+			continue
+		}
+
+		// end of a param
+		if colon >= 0 {
+			removed = append(removed, line[colon+1:index])
+			line = line[:colon] + line[index:]
+			index = colon
+		}
+
+		if line[index] == ')' {
+			break
+		}
+
+		colon = -1
+		count--
+	}
+
+	return line, strings.Join(removed, ", ")
+}
+
+// removeUnusedImports removes the vuex types and the given names from the
+// named imports when they are no longer referenced
+func (mg *migration) removeUnusedImports(lines []string, names []string, filename string) []string {
+	var result = []string{}
+	var code = []string{}
+
+	for _, line := range lines {
+		if typePattern["named_import"].FindStringSubmatch(line) == nil {
+			code = append(code, line)
+		}
+	}
+
+	var content = strings.Join(code, "\n")
+
+	for index := 0; index < len(lines); index++ {
+		var line = lines[index]
+
+		match := typePattern["named_import"].FindStringSubmatch(line)
+		if match == nil {
+			result = append(result, line)
+			continue
+		}
+
+		var isVuex = match[4] == "vuex"
+		var kept = []string{}
+		var specifiers = 0
+
+		for _, specifier := range strings.Split(match[2], ",") {
+			var specifier = strings.TrimSpace(specifier)
+			if specifier == "" {
+				continue
+			}
+
+			specifiers++
+
+			var name = strings.TrimSpace(strings.TrimPrefix(specifier, "type "))
+			if _, alias, ok := strings.Cut(name, " as "); ok {
+				name = strings.TrimSpace(alias)
+			}
+
+			var used = wordPattern(name).FindStringSubmatch(content) != nil
+
+			if isVuex && slices.Contains(vuexTypes, name) && used {
+				mg.addDiagnostic(filename, index+1, "vuex type %s is still used, it must be replaced by hand", name)
+			}
+
+			if used || !(isVuex && slices.Contains(vuexTypes, name) || slices.Contains(names, name)) {
+				kept = append(kept, specifier)
+			}
+		}
+
+		if len(kept) == specifiers {
+			result = append(result, line)
+			continue
+		}
+
+		if len(kept) == 0 {
+			index = skipBlankLine(result, lines, index)
+			continue
+		}
+
+		result = append(result, fmt.Sprintf("import %s{ %s } from %s%s%s;", match[1], strings.Join(kept, ", "), match[3], match[4], match[5]))
+	}
+
+	return result
+}
+
+// skipBlankLine returns the index of the blank line following a removed line
+// when it would leave two blank lines, or a blank line at the top of the file
+func skipBlankLine(result []string, lines []string, index int) int {
+	if index+1 < len(lines) && strings.TrimSpace(lines[index+1]) == "" && (len(result) == 0 || strings.TrimSpace(result[len(result)-1]) == "") {
+		return index + 1
+	}
+
+	return index
+}
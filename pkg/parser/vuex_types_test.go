@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStripParamTypes(t *testing.T) {
+	var tests = []struct {
+		line     string
+		count    int
+		expected string
+		types    string
+	}{
+		{"  add(state: CartState, item: string) {", 1, "  add(state, item: string) {", " CartState"},
+		{"  total(state: CartState, getters: Getters): number {", 2, "  total(state, getters): number {", " CartState,  Getters"},
+		{"  load(item: string) {", 0, "  load(item: string) {", ""},
+	}
+
+	for _, test := range tests {
+		line, types := stripParamTypes(test.line, test.count)
+		if line != test.expected || types != test.types {
+			t.Errorf("stripParamTypes(%q, %d) = %q, %q", test.line, test.count, line, types)
+		}
+	}
+}
+
+func TestMigrateStripsVuexTypes(t *testing.T) {
+	var root = t.TempDir()
+	writeModule(t, root, ".", map[string]string{"types.ts": "export interface RootState {\n  cart: unknown;\n}\n"})
+	writeModule(t, root, "cart", map[string]string{
+		"types.ts":     "export interface CartState {\n  items: string[];\n}\n",
+		"state.ts":     "import { CartState } from './types';\n\nexport default (): CartState => ({\n  items: [],\n});\n",
+		"getters.ts":   "import { GetterTree } from 'vuex';\nimport { CartState } from './types';\nimport { RootState } from '../types';\n\nexport const getters: GetterTree<CartState, RootState> = {\n  count(state: CartState): number {\n    return state.items.length;\n  },\n};\n\nexport default getters;",
+		"mutations.ts": "import { MutationTree } from 'vuex';\nimport { CartState } from './types';\n\nconst mutations: MutationTree<CartState> = {\n  add(state: CartState, item: string) {\n    state.items.push(item);\n  },\n};\n\nexport default mutations;\n",
+		"actions.ts":   "import { ActionTree, ActionContext } from 'vuex';\nimport { CartState } from './types';\nimport { RootState } from '../types';\n\ntype Context = ActionContext<CartState, RootState>;\n\nconst actions: ActionTree<CartState, RootState> = {\n  load({ commit }: Context, item: string) {\n    commit('add', item);\n  },\n  save(context: ActionContext<CartState, RootState>) {\n    return context.state.items;\n  },\n};\n\nexport default actions;",
+	})
+
+	if _, err := Migrate(context.Background(), root, Config{NoCache: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	var expected = map[string]string{
+		"getters.ts": "export const getters = {\n  count: (state): number => state.items.length,\n};\n\nexport default getters;",
+		"actions.ts": "const actions = {\n  load(item: string) {\n    this.add(item);\n  },\n  save() {\n    return this.items;\n  },\n\n  add(item: string) {\n    this.items.push(item);\n  },\n};\n\nexport default actions;",
+	}
+
+	for name, content := range expected {
+		migrated, err := os.ReadFile(filepath.Join(root, "cart", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(migrated) != content {
+			t.Errorf("%s migrated as\n%s", name, migrated)
+		}
+	}
+}